@@ -1,3 +1,5 @@
 module github.com/mohamedation/GoSorter
 
 go 1.23.4
+
+require golang.org/x/image v0.25.0
@@ -0,0 +1,381 @@
+// Package helpers - audio tags
+package helpers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// AudioTags - what is needed to file a track as Artist/Album/NN - Title
+type AudioTags struct {
+	Artist      string
+	AlbumArtist string
+	Album       string
+	Title       string
+	Track       int
+}
+
+var errNoTags = errors.New("no audio tags found")
+
+const maxTagSize = 16 << 20 // ID3v2 and vorbis comments, cover art can make these large
+
+// ReadAudioTags - reads ID3v2/ID3v1 (mp3), Vorbis comments (flac) and MP4 ilst atoms (m4a)
+func ReadAudioTags(filePath string) (*AudioTags, error) {
+	filePath = filepath.Clean(filePath)
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 10)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return nil, errNoTags
+	}
+
+	var tags *AudioTags
+	switch {
+	case string(header[:3]) == "ID3":
+		tags, _ = readID3v2(file, header)
+	case string(header[:4]) == "fLaC":
+		tags, _ = readFLACTags(file, info.Size())
+	case string(header[4:8]) == "ftyp":
+		tags, _ = readMP4Tags(file, info.Size())
+	}
+
+	// ID3v1 sits in the last 128 bytes, used to fill the gaps
+	if v1, err := readID3v1(file, info.Size()); err == nil {
+		if tags == nil {
+			tags = v1
+		} else {
+			tags.fillFrom(v1)
+		}
+	}
+
+	if tags == nil || tags.isEmpty() {
+		return nil, errNoTags
+	}
+	return tags, nil
+}
+
+func (t *AudioTags) isEmpty() bool {
+	return t.Artist == "" && t.AlbumArtist == "" && t.Album == "" && t.Title == ""
+}
+
+func (t *AudioTags) fillFrom(other *AudioTags) {
+	if t.Artist == "" {
+		t.Artist = other.Artist
+	}
+	if t.Album == "" {
+		t.Album = other.Album
+	}
+	if t.Title == "" {
+		t.Title = other.Title
+	}
+	if t.Track == 0 {
+		t.Track = other.Track
+	}
+}
+
+// "3/12" -> 3
+func parseTrackNumber(s string) int {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '/'); i >= 0 {
+		s = s[:i]
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+func syncsafe(b []byte) int {
+	return int(b[0]&0x7F)<<21 | int(b[1]&0x7F)<<14 | int(b[2]&0x7F)<<7 | int(b[3]&0x7F)
+}
+
+func readID3v2(r io.ReaderAt, header []byte) (*AudioTags, error) {
+	version := header[3]
+	flags := header[5]
+	size := syncsafe(header[6:10])
+	if version < 2 || version > 4 || size <= 0 || size > maxTagSize {
+		return nil, errNoTags
+	}
+	data := make([]byte, size)
+	if _, err := r.ReadAt(data, 10); err != nil && !errors.Is(err, io.EOF) {
+		return nil, errNoTags
+	}
+
+	// whole-tag unsynchronisation (v2.2/v2.3), v2.4 does it per frame
+	if flags&0x80 != 0 && version < 4 {
+		data = bytes.ReplaceAll(data, []byte{0xFF, 0x00}, []byte{0xFF})
+	}
+	if flags&0x40 != 0 && version >= 3 && len(data) >= 4 {
+		extSize := int(binary.BigEndian.Uint32(data[:4]))
+		if version == 3 {
+			extSize += 4
+		} else {
+			extSize = syncsafe(data[:4])
+		}
+		if extSize > len(data) {
+			return nil, errNoTags
+		}
+		data = data[extSize:]
+	}
+
+	idLen, headerLen := 4, 10
+	if version == 2 {
+		idLen, headerLen = 3, 6
+	}
+
+	tags := &AudioTags{}
+	for len(data) >= headerLen && data[0] != 0 {
+		id := string(data[:idLen])
+		var frameSize int
+		switch version {
+		case 2:
+			frameSize = int(data[3])<<16 | int(data[4])<<8 | int(data[5])
+		case 3:
+			frameSize = int(binary.BigEndian.Uint32(data[4:8]))
+		default:
+			frameSize = syncsafe(data[4:8])
+		}
+		if frameSize <= 0 || headerLen+frameSize > len(data) {
+			break
+		}
+		frame := data[headerLen : headerLen+frameSize]
+		data = data[headerLen+frameSize:]
+
+		switch id {
+		case "TPE1", "TP1":
+			tags.Artist = decodeID3Text(frame)
+		case "TPE2", "TP2":
+			tags.AlbumArtist = decodeID3Text(frame)
+		case "TALB", "TAL":
+			tags.Album = decodeID3Text(frame)
+		case "TIT2", "TT2":
+			tags.Title = decodeID3Text(frame)
+		case "TRCK", "TRK":
+			tags.Track = parseTrackNumber(decodeID3Text(frame))
+		}
+	}
+	return tags, nil
+}
+
+// text frames start with an encoding byte, multiple values are NUL separated (first one wins)
+func decodeID3Text(frame []byte) string {
+	if len(frame) < 2 {
+		return ""
+	}
+	enc, text := frame[0], frame[1:]
+	var s string
+	switch enc {
+	case 1, 2: // UTF-16 with BOM, UTF-16BE
+		order := binary.ByteOrder(binary.BigEndian)
+		if enc == 1 && len(text) >= 2 {
+			if text[0] == 0xFF && text[1] == 0xFE {
+				order = binary.LittleEndian
+			}
+			if (text[0] == 0xFF && text[1] == 0xFE) || (text[0] == 0xFE && text[1] == 0xFF) {
+				text = text[2:]
+			}
+		}
+		units := make([]uint16, 0, len(text)/2)
+		for i := 0; i+1 < len(text); i += 2 {
+			u := order.Uint16(text[i:])
+			if u == 0 {
+				break
+			}
+			units = append(units, u)
+		}
+		s = string(utf16.Decode(units))
+	case 3: // UTF-8
+		if i := bytes.IndexByte(text, 0); i >= 0 {
+			text = text[:i]
+		}
+		s = string(text)
+	default: // ISO-8859-1
+		s = latin1(text)
+	}
+	return strings.TrimSpace(s)
+}
+
+func latin1(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+func readID3v1(r io.ReaderAt, size int64) (*AudioTags, error) {
+	if size < 128 {
+		return nil, errNoTags
+	}
+	tag := make([]byte, 128)
+	if _, err := r.ReadAt(tag, size-128); err != nil || string(tag[:3]) != "TAG" {
+		return nil, errNoTags
+	}
+	tags := &AudioTags{
+		Title:  strings.TrimSpace(latin1(tag[3:33])),
+		Artist: strings.TrimSpace(latin1(tag[33:63])),
+		Album:  strings.TrimSpace(latin1(tag[63:93])),
+	}
+	// ID3v1.1 keeps the track in the last byte of the comment
+	if tag[125] == 0 && tag[126] != 0 {
+		tags.Track = int(tag[126])
+	}
+	if tags.isEmpty() {
+		return nil, errNoTags
+	}
+	return tags, nil
+}
+
+func readFLACTags(r io.ReaderAt, size int64) (*AudioTags, error) {
+	offset := int64(4)
+	head := make([]byte, 4)
+	for offset+4 <= size {
+		if _, err := r.ReadAt(head, offset); err != nil {
+			return nil, errNoTags
+		}
+		last := head[0]&0x80 != 0
+		blockType := head[0] & 0x7F
+		length := int64(head[1])<<16 | int64(head[2])<<8 | int64(head[3])
+		if blockType == 4 { // VORBIS_COMMENT
+			if length > maxTagSize {
+				return nil, errNoTags
+			}
+			block := make([]byte, length)
+			if _, err := r.ReadAt(block, offset+4); err != nil {
+				return nil, errNoTags
+			}
+			return parseVorbisComments(block), nil
+		}
+		if last {
+			break
+		}
+		offset += 4 + length
+	}
+	return nil, errNoTags
+}
+
+func parseVorbisComments(block []byte) *AudioTags {
+	tags := &AudioTags{}
+	if len(block) < 4 {
+		return tags
+	}
+	vendorLen := int(binary.LittleEndian.Uint32(block))
+	p := 4 + vendorLen
+	if p+4 > len(block) {
+		return tags
+	}
+	count := int(binary.LittleEndian.Uint32(block[p:]))
+	p += 4
+	for i := 0; i < count && p+4 <= len(block); i++ {
+		n := int(binary.LittleEndian.Uint32(block[p:]))
+		p += 4
+		if n < 0 || p+n > len(block) {
+			break
+		}
+		key, value, ok := strings.Cut(string(block[p:p+n]), "=")
+		p += n
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToUpper(key) {
+		case "ARTIST":
+			if tags.Artist == "" {
+				tags.Artist = value
+			}
+		case "ALBUMARTIST", "ALBUM ARTIST":
+			if tags.AlbumArtist == "" {
+				tags.AlbumArtist = value
+			}
+		case "ALBUM":
+			if tags.Album == "" {
+				tags.Album = value
+			}
+		case "TITLE":
+			if tags.Title == "" {
+				tags.Title = value
+			}
+		case "TRACKNUMBER":
+			if tags.Track == 0 {
+				tags.Track = parseTrackNumber(value)
+			}
+		}
+	}
+	return tags
+}
+
+// moov/udta/meta/ilst
+func readMP4Tags(r io.ReaderAt, size int64) (*AudioTags, error) {
+	moov, err := findBox(r, 0, size, "moov")
+	if err != nil {
+		return nil, errNoTags
+	}
+	udta, err := findBox(r, moov.offset, moov.offset+moov.size, "udta")
+	if err != nil {
+		return nil, errNoTags
+	}
+	meta, err := findBox(r, udta.offset, udta.offset+udta.size, "meta")
+	if err != nil {
+		return nil, errNoTags
+	}
+	// meta is a full box, skip version + flags
+	ilst, err := findBox(r, meta.offset+4, meta.offset+meta.size, "ilst")
+	if err != nil || ilst.size > maxTagSize {
+		return nil, errNoTags
+	}
+	buf := make([]byte, ilst.size)
+	if _, err := r.ReadAt(buf, ilst.offset); err != nil {
+		return nil, errNoTags
+	}
+
+	tags := &AudioTags{}
+	for len(buf) >= 8 {
+		itemSize := int(binary.BigEndian.Uint32(buf[:4]))
+		if itemSize < 8 || itemSize > len(buf) {
+			break
+		}
+		name := string(buf[4:8])
+		data := childBox(buf[8:itemSize], "data")
+		buf = buf[itemSize:]
+		// data: 4 bytes type, 4 bytes locale, then the value
+		if len(data) < 8 {
+			continue
+		}
+		value := data[8:]
+		switch name {
+		case "\xa9ART":
+			tags.Artist = strings.TrimSpace(string(value))
+		case "aART":
+			tags.AlbumArtist = strings.TrimSpace(string(value))
+		case "\xa9alb":
+			tags.Album = strings.TrimSpace(string(value))
+		case "\xa9nam":
+			tags.Title = strings.TrimSpace(string(value))
+		case "trkn":
+			if len(value) >= 4 {
+				tags.Track = int(binary.BigEndian.Uint16(value[2:4]))
+			}
+		}
+	}
+	return tags, nil
+}
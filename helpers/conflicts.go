@@ -0,0 +1,178 @@
+// Package helpers - conflict resolution
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mohamedation/GoSorter/model"
+)
+
+// PlaceOutcome - what PlaceFile did with a file
+type PlaceOutcome int
+
+const (
+	Placed     PlaceOutcome = iota // moved to the target folder, renamed or replacing the existing file
+	Skipped                        // left where it was, the conflict policy kept the existing file
+	Duplicated                     // identical to the existing file, moved to Duplicates
+	Failed                         // not moved, the error is logged
+	Unchanged                      // the destination is the file itself, nothing to do
+)
+
+// PlaceFile - moves fileName to targetFolder/newName, conflict decides what happens when the
+// destination exists. returns where the file ended up, empty when it was skipped or failed
+func PlaceFile(ctx context.Context, folderPath, fileName, targetFolder, newName string, conflict model.Conflict, cfg model.Config, logger Logger) (string, PlaceOutcome) {
+	targetPath := TargetPath(folderPath, targetFolder)
+	if !FolderExists(targetPath) {
+		if err := os.MkdirAll(targetPath, 0750); err != nil {
+			logger.Log(cfg, Error, fmt.Sprintf("Failed to create folder %s: %v\n", targetPath, err))
+			return "", Failed
+		}
+	}
+
+	srcPath := filepath.Join(folderPath, fileName)
+	dstPath := filepath.Join(targetPath, newName)
+
+	// a folder or template resolving to the sorted directory itself: the file would be found
+	// identical to the "existing" destination, which is the file, and deleted
+	if srcInfo, err := os.Lstat(srcPath); err == nil {
+		if dstInfo, err := os.Lstat(dstPath); err == nil && os.SameFile(srcInfo, dstInfo) {
+			logger.Log(cfg, Debug, fmt.Sprintf("Already in place: %s\n", FormatPath(srcPath, cfg)))
+			return dstPath, Unchanged
+		}
+	}
+
+	replacing := false // the move replaces dstPath, see Discard
+	if FileExists(dstPath) {
+		policy := conflict.Policy
+		if policy == model.ConflictPrompt {
+			policy = model.ConflictRename
+			if conflict.Ask != nil {
+				policy = conflict.Ask(srcPath, dstPath)
+			}
+		}
+
+		switch policy {
+		case model.ConflictSkip:
+			return skipConflict(srcPath, dstPath, cfg, logger)
+		case model.ConflictOverwrite:
+			logger.Log(cfg, Debug, fmt.Sprintf("Replacing file: %s\n", FormatPath(dstPath, cfg)))
+			replacing = true
+		case model.ConflictOverwriteIfNewer, model.ConflictKeepLarger:
+			srcInfo, srcErr := os.Stat(srcPath)
+			dstInfo, dstErr := os.Stat(dstPath)
+			if srcErr != nil || dstErr != nil {
+				logger.Log(cfg, Error, fmt.Sprintf("Failed to compare %s with %s\n", srcPath, dstPath))
+				return "", Failed
+			}
+			replace := srcInfo.Size() > dstInfo.Size()
+			if policy == model.ConflictOverwriteIfNewer {
+				replace = srcInfo.ModTime().After(dstInfo.ModTime())
+			}
+			if !replace {
+				return skipConflict(srcPath, dstPath, cfg, logger)
+			}
+			logger.Log(cfg, Debug, fmt.Sprintf("Replacing file: %s\n", FormatPath(dstPath, cfg)))
+			replacing = true
+		default: // rename, duplicates
+			same, err := sameContent(ctx, srcPath, dstPath, cfg, logger)
+			if err != nil {
+				return "", Failed
+			}
+			switch {
+			case same && policy == model.ConflictDuplicates:
+				// the existing file is not touched at all
+				if dup := MoveDuplicateFile(ctx, folderPath, fileName, dstPath, conflict.DuplicatesFolder, cfg, logger); dup != "" {
+					return dup, Duplicated
+				}
+				return "", Failed
+			case same:
+				if err := Discard(dstPath, cfg, logger); err != nil {
+					logger.Log(cfg, Error, fmt.Sprintf("Failed to overwrite file %s: %v\n", dstPath, err))
+					return "", Failed
+				}
+				logger.Log(cfg, Debug, fmt.Sprintf("Overwriting file: %s\n", FormatPath(dstPath, cfg)))
+			default:
+				existing := dstPath
+				dstPath = conflictPath(targetPath, newName, conflict.RenamePattern)
+				logger.Log(cfg, Debug, fmt.Sprintf("File conflict: %s exists, renaming to %s\n", FormatPath(existing, cfg), FormatPath(dstPath, cfg)))
+			}
+		}
+	}
+
+	// without the Trash the move replaces the file in one rename
+	if replacing && cfg.UseTrash {
+		if err := Discard(dstPath, cfg, logger); err != nil {
+			logger.Log(cfg, Error, fmt.Sprintf("Failed to replace file %s: %v\n", dstPath, err))
+			return "", Failed
+		}
+	}
+
+	if err := MoveFile(ctx, srcPath, dstPath, cfg, logger); err != nil {
+		logger.Log(cfg, Error, fmt.Sprintf("Failed to move file %s: %v\n", srcPath, err))
+		return "", Failed
+	}
+	logger.Log(cfg, Info, fmt.Sprintf("Moved: %s -> %s\n", FormatPath(srcPath, cfg), FormatPath(dstPath, cfg)))
+	return dstPath, Placed
+}
+
+func skipConflict(srcPath, dstPath string, cfg model.Config, logger Logger) (string, PlaceOutcome) {
+	logger.Log(cfg, Info, fmt.Sprintf("Skipped: %s, %s already exists\n", FormatPath(srcPath, cfg), FormatPath(dstPath, cfg)))
+	return "", Skipped
+}
+
+// sameContent - files too large to hash (-S) are never considered identical
+func sameContent(ctx context.Context, srcPath, dstPath string, cfg model.Config, logger Logger) (bool, error) {
+	srcInfo, err := os.Stat(srcPath)
+	if err != nil {
+		logger.Log(cfg, Error, fmt.Sprintf("Failed to stat source file %s: %v\n", srcPath, err))
+		return false, err
+	}
+	dstInfo, err := os.Stat(dstPath)
+	if err != nil {
+		logger.Log(cfg, Error, fmt.Sprintf("Failed to stat destination file %s: %v\n", dstPath, err))
+		return false, err
+	}
+	if srcInfo.Size() != dstInfo.Size() {
+		return false, nil
+	}
+
+	maxBytes := cfg.MaxHashFileSizeMB
+	if maxBytes <= 0 {
+		maxBytes = 1024
+	}
+	maxBytes = maxBytes * 1024 * 1024 // MB to bytes
+
+	logger.Log(cfg, Debug, fmt.Sprintf("Hashing source file: %s\n", FormatPath(srcPath, cfg)))
+	srcHash, err := HashFile(ctx, srcPath, maxBytes, cfg, logger)
+	if err != nil {
+		logger.Log(cfg, Error, fmt.Sprintf("Failed to hash source file %s: %v\n", srcPath, err))
+		return false, err
+	}
+	logger.Log(cfg, Debug, fmt.Sprintf("Hashing destination file: %s\n", FormatPath(dstPath, cfg)))
+	dstHash, err := HashFile(ctx, dstPath, maxBytes, cfg, logger)
+	if err != nil {
+		logger.Log(cfg, Error, fmt.Sprintf("Failed to hash destination file %s: %v\n", dstPath, err))
+		return false, err
+	}
+	return srcHash != "" && srcHash == dstHash, nil
+}
+
+// conflictPath - first free name from the rename pattern, counting from 1
+func conflictPath(targetPath, newName, pattern string) string {
+	if pattern == "" {
+		pattern = model.DefaultConflictRenamePattern
+	}
+	ext := filepath.Ext(newName)
+	name := strings.TrimSuffix(newName, ext)
+	for i := 1; ; i++ {
+		candidate := strings.NewReplacer("{name}", name, "{n}", strconv.Itoa(i), "{ext}", ext).Replace(pattern)
+		if path := filepath.Join(targetPath, candidate); !FileExists(path) {
+			return path
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// Package helpers - dates
+package helpers
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mohamedation/GoSorter/model"
+)
+
+var (
+	// 2024-03-01, 2024_03_01, 2024.03.01
+	separatedDatePattern = regexp.MustCompile(`(?:^|\D)((?:19|20)\d{2})[-_.](\d{2})[-_.](\d{2})(?:\D|$)`)
+	// IMG_20240301_101010, 20240301-statement
+	compactDatePattern = regexp.MustCompile(`(?:^|\D)((?:19|20)\d{2})(\d{2})(\d{2})(?:\D|$)`)
+)
+
+// DateFromFileName - finds a calendar date in names like "2024-03-01_statement.pdf" or "IMG_20240301_1010.jpg"
+func DateFromFileName(fileName string) (time.Time, bool) {
+	name := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	for _, re := range []*regexp.Regexp{separatedDatePattern, compactDatePattern} {
+		for _, m := range re.FindAllStringSubmatch(name, -1) {
+			year, _ := strconv.Atoi(m[1])
+			month, _ := strconv.Atoi(m[2])
+			day, _ := strconv.Atoi(m[3])
+			t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.Local)
+			// time.Date normalizes 2024-02-31 into March, reject those
+			if t.Year() == year && int(t.Month()) == month && t.Day() == day {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// FileDate - date of a file according to the configured source, falls back to mtime
+func FileDate(filePath string, source string) time.Time {
+	if source == model.DateSourceFilename {
+		if t, ok := DateFromFileName(filepath.Base(filePath)); ok {
+			return t
+		}
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return time.Now()
+	}
+	if source == model.DateSourceCtime {
+		if t := creationTime(info); !t.IsZero() {
+			return t
+		}
+	}
+	return info.ModTime()
+}
+
+// HasDateTokens - true if a folder pattern needs a file date
+func HasDateTokens(pattern string) bool {
+	return strings.Contains(pattern, "{yyyy}") || strings.Contains(pattern, "{yy}") ||
+		strings.Contains(pattern, "{mm}") || strings.Contains(pattern, "{dd}")
+}
+
+// ExpandDateTokens - replaces {yyyy}, {yy}, {mm}, {dd} in a folder pattern
+func ExpandDateTokens(pattern string, t time.Time) string {
+	return strings.NewReplacer(
+		"{yyyy}", t.Format("2006"),
+		"{yy}", t.Format("06"),
+		"{mm}", t.Format("01"),
+		"{dd}", t.Format("02"),
+	).Replace(pattern)
+}
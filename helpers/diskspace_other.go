@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !freebsd && !dragonfly && !windows
+
+// Package helpers - free disk space (other platforms)
+package helpers
+
+import "errors"
+
+// FreeSpace - not available, the space check is skipped
+func FreeSpace(_ string) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
@@ -0,0 +1,18 @@
+//go:build linux || darwin || freebsd || dragonfly
+
+// Package helpers - free disk space (statfs)
+package helpers
+
+import "syscall"
+
+// FreeSpace - bytes available to the current user on the filesystem of path
+func FreeSpace(path string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	if st.Bavail <= 0 { // signed on the BSDs, negative when root's reserve is in use
+		return 0, nil
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
@@ -0,0 +1,349 @@
+// Package helpers - document metadata
+package helpers
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// DocumentMeta - common metadata of PDFs and office documents
+type DocumentMeta struct {
+	Title    string
+	Author   string
+	Producer string // PDF Producer/Creator, office Application/generator
+	Created  time.Time
+}
+
+var errNoDocumentMeta = errors.New("no document metadata found")
+
+const pdfScanSize = 1 << 20 // head and tail of a PDF searched for the Info dictionary and XMP
+
+// ReadDocumentMeta - PDF (Info dictionary, XMP), OOXML (docProps) and ODF (meta.xml)
+func ReadDocumentMeta(filePath string) (*DocumentMeta, error) {
+	var meta *DocumentMeta
+	var err error
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".pdf":
+		meta, err = readPDFMeta(filePath)
+	case ".docx", ".xlsx", ".pptx", ".docm", ".xlsm", ".pptm":
+		meta, err = readOOXMLMeta(filePath)
+	case ".odt", ".ods", ".odp", ".odg":
+		meta, err = readODFMeta(filePath)
+	default:
+		return nil, errNoDocumentMeta
+	}
+	if err != nil {
+		return nil, err
+	}
+	if meta.Title == "" && meta.Author == "" && meta.Producer == "" && meta.Created.IsZero() {
+		return nil, errNoDocumentMeta
+	}
+	return meta, nil
+}
+
+func readOOXMLMeta(filePath string) (*DocumentMeta, error) {
+	r, err := zip.OpenReader(filepath.Clean(filePath))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = r.Close() }()
+
+	var core struct {
+		Title   string `xml:"title"`
+		Creator string `xml:"creator"`
+		Created string `xml:"created"`
+	}
+	var app struct {
+		Application string `xml:"Application"`
+	}
+	coreErr := readZipXML(&r.Reader, "docProps/core.xml", &core)
+	appErr := readZipXML(&r.Reader, "docProps/app.xml", &app)
+	if coreErr != nil && appErr != nil {
+		return nil, errNoDocumentMeta
+	}
+	return &DocumentMeta{
+		Title:    strings.TrimSpace(core.Title),
+		Author:   strings.TrimSpace(core.Creator),
+		Producer: strings.TrimSpace(app.Application),
+		Created:  parseW3CDate(core.Created),
+	}, nil
+}
+
+func readODFMeta(filePath string) (*DocumentMeta, error) {
+	r, err := zip.OpenReader(filepath.Clean(filePath))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = r.Close() }()
+
+	var doc struct {
+		Meta struct {
+			Title          string `xml:"title"`
+			Creator        string `xml:"creator"`
+			InitialCreator string `xml:"initial-creator"`
+			CreationDate   string `xml:"creation-date"`
+			Generator      string `xml:"generator"`
+		} `xml:"meta"`
+	}
+	if err := readZipXML(&r.Reader, "meta.xml", &doc); err != nil {
+		return nil, errNoDocumentMeta
+	}
+	author := doc.Meta.InitialCreator
+	if author == "" {
+		author = doc.Meta.Creator
+	}
+	return &DocumentMeta{
+		Title:    strings.TrimSpace(doc.Meta.Title),
+		Author:   strings.TrimSpace(author),
+		Producer: strings.TrimSpace(doc.Meta.Generator),
+		Created:  parseW3CDate(doc.Meta.CreationDate),
+	}, nil
+}
+
+func parseW3CDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+var (
+	pdfInfoRefPattern = regexp.MustCompile(`/Info\s+(\d+)\s+(\d+)\s+R`)
+	xmpBlockPattern   = regexp.MustCompile(`(?s)<x:xmpmeta.*?</x:xmpmeta>`)
+)
+
+// readPDFMeta - Info dictionary when it is stored as a plain object, XMP as a fallback.
+// objects inside compressed object streams are not decoded.
+func readPDFMeta(filePath string) (*DocumentMeta, error) {
+	file, err := os.Open(filepath.Clean(filePath))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	head := make([]byte, min(info.Size(), pdfScanSize))
+	if _, err := io.ReadFull(file, head); err != nil {
+		return nil, errNoDocumentMeta
+	}
+	if !bytes.HasPrefix(head, []byte("%PDF")) {
+		return nil, errNoDocumentMeta
+	}
+	data := head
+	if info.Size() > pdfScanSize {
+		tail := make([]byte, pdfScanSize)
+		if _, err := file.ReadAt(tail, info.Size()-pdfScanSize); err == nil {
+			data = append(append([]byte{}, head...), tail...)
+		}
+	}
+
+	meta := &DocumentMeta{}
+	// last trailer wins (incremental updates append new ones)
+	refs := pdfInfoRefPattern.FindAllSubmatch(data, -1)
+	if len(refs) > 0 {
+		ref := refs[len(refs)-1]
+		objPattern := regexp.MustCompile(`(?s)(?:^|\s)` + string(ref[1]) + `\s+` + string(ref[2]) + `\s+obj\s*<<(.*?)>>\s*endobj`)
+		if m := objPattern.FindAllSubmatch(data, -1); len(m) > 0 {
+			dict := m[len(m)-1][1]
+			meta.Title = pdfDictString(dict, "Title")
+			meta.Author = pdfDictString(dict, "Author")
+			meta.Producer = pdfDictString(dict, "Producer")
+			if meta.Producer == "" {
+				meta.Producer = pdfDictString(dict, "Creator")
+			}
+			meta.Created = parsePDFDate(pdfDictString(dict, "CreationDate"))
+		}
+	}
+
+	if xmp := xmpBlockPattern.FindAll(data, -1); len(xmp) > 0 {
+		fillFromXMP(meta, xmp[len(xmp)-1])
+	}
+	return meta, nil
+}
+
+// pdfDictString - value of /Key (literal) or /Key <hex> in a dictionary
+func pdfDictString(dict []byte, key string) string {
+	idx := bytes.Index(dict, []byte("/"+key))
+	for idx >= 0 {
+		rest := dict[idx+len(key)+1:]
+		// /Title must not match /TitleSomething
+		if len(rest) > 0 && (isPDFNameChar(rest[0])) {
+			next := bytes.Index(rest, []byte("/"+key))
+			if next < 0 {
+				return ""
+			}
+			idx += len(key) + 1 + next
+			continue
+		}
+		rest = bytes.TrimLeft(rest, " \t\r\n")
+		if len(rest) == 0 {
+			return ""
+		}
+		switch rest[0] {
+		case '(':
+			return decodePDFText(parsePDFLiteral(rest))
+		case '<':
+			return decodePDFText(parsePDFHex(rest))
+		}
+		return ""
+	}
+	return ""
+}
+
+func isPDFNameChar(c byte) bool {
+	return c != ' ' && c != '\t' && c != '\r' && c != '\n' && c != '(' && c != '<' && c != '/' && c != '['
+}
+
+func parsePDFLiteral(b []byte) []byte {
+	var out []byte
+	depth := 0
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		switch {
+		case c == '\\' && i+1 < len(b):
+			i++
+			switch e := b[i]; e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r', '\n':
+				// line continuation
+			default:
+				if e >= '0' && e <= '7' {
+					j := i
+					for j < len(b) && j < i+3 && b[j] >= '0' && b[j] <= '7' {
+						j++
+					}
+					v, _ := strconv.ParseUint(string(b[i:j]), 8, 8)
+					out = append(out, byte(v))
+					i = j - 1
+				} else {
+					out = append(out, e)
+				}
+			}
+		case c == '(':
+			depth++
+			if depth > 1 {
+				out = append(out, c)
+			}
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return out
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func parsePDFHex(b []byte) []byte {
+	end := bytes.IndexByte(b, '>')
+	if end < 0 {
+		return nil
+	}
+	hex := bytes.Map(func(r rune) rune {
+		if strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return r
+		}
+		return -1
+	}, b[1:end])
+	if len(hex)%2 == 1 {
+		hex = append(hex, '0')
+	}
+	out := make([]byte, len(hex)/2)
+	for i := range out {
+		v, _ := strconv.ParseUint(string(hex[2*i:2*i+2]), 16, 8)
+		out[i] = byte(v)
+	}
+	return out
+}
+
+// text strings are UTF-16BE with a BOM or PDFDocEncoding (latin1 for our purposes)
+func decodePDFText(b []byte) string {
+	if len(b) >= 2 && b[0] == 0xFE && b[1] == 0xFF {
+		units := make([]uint16, 0, len(b)/2)
+		for i := 2; i+1 < len(b); i += 2 {
+			units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+		}
+		return strings.TrimSpace(string(utf16.Decode(units)))
+	}
+	if len(b) >= 3 && b[0] == 0xEF && b[1] == 0xBB && b[2] == 0xBF {
+		return strings.TrimSpace(string(b[3:]))
+	}
+	return strings.TrimSpace(latin1(b))
+}
+
+// D:YYYYMMDDHHmmSSOHH'mm, everything after the year is optional
+func parsePDFDate(s string) time.Time {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "D:")
+	digits := 0
+	for digits < len(s) && digits < 14 && s[digits] >= '0' && s[digits] <= '9' {
+		digits++
+	}
+	if digits < 4 {
+		return time.Time{}
+	}
+	num := s[:digits] + "0101000000"[max(0, digits-4):]
+	t, err := time.ParseInLocation("20060102150405", num[:14], time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+var (
+	xmpTitlePattern    = regexp.MustCompile(`(?s)<dc:title>.*?<rdf:li[^>]*>(.*?)</rdf:li>`)
+	xmpCreatorPattern  = regexp.MustCompile(`(?s)<dc:creator>.*?<rdf:li[^>]*>(.*?)</rdf:li>`)
+	xmpProducerPattern = regexp.MustCompile(`(?s)pdf:Producer(?:>(.*?)</pdf:Producer|="([^"]*)")`)
+	xmpCreatedPattern  = regexp.MustCompile(`(?s)xmp:CreateDate(?:>(.*?)</xmp:CreateDate|="([^"]*)")`)
+)
+
+// fillFromXMP - XMP values only fill what the Info dictionary did not have
+func fillFromXMP(meta *DocumentMeta, xmp []byte) {
+	first := func(m [][]byte) string {
+		for _, g := range m[1:] {
+			if len(g) > 0 {
+				return strings.TrimSpace(html.UnescapeString(string(g)))
+			}
+		}
+		return ""
+	}
+	if m := xmpTitlePattern.FindSubmatch(xmp); m != nil && meta.Title == "" {
+		meta.Title = first(m)
+	}
+	if m := xmpCreatorPattern.FindSubmatch(xmp); m != nil && meta.Author == "" {
+		meta.Author = first(m)
+	}
+	if m := xmpProducerPattern.FindSubmatch(xmp); m != nil && meta.Producer == "" {
+		meta.Producer = first(m)
+	}
+	if m := xmpCreatedPattern.FindSubmatch(xmp); m != nil && meta.Created.IsZero() {
+		meta.Created = parseW3CDate(first(m))
+	}
+}
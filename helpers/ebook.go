@@ -0,0 +1,183 @@
+// Package helpers - ebook metadata
+package helpers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// EbookMeta - title and first author
+type EbookMeta struct {
+	Title  string
+	Author string
+}
+
+var errNoEbookMeta = errors.New("no ebook metadata found")
+
+const maxOPFSize = 4 << 20
+
+// ReadEbookMeta - reads EPUB (OPF) or MOBI/AZW3 (PalmDB + EXTH) metadata
+func ReadEbookMeta(filePath string) (*EbookMeta, error) {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".epub":
+		return readEPUBMeta(filePath)
+	case ".mobi", ".azw", ".azw3", ".prc":
+		return readMOBIMeta(filePath)
+	}
+	return nil, errNoEbookMeta
+}
+
+func readEPUBMeta(filePath string) (*EbookMeta, error) {
+	r, err := zip.OpenReader(filepath.Clean(filePath))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = r.Close() }()
+
+	var container struct {
+		Rootfiles []struct {
+			FullPath string `xml:"full-path,attr"`
+		} `xml:"rootfiles>rootfile"`
+	}
+	if err := readZipXML(&r.Reader, "META-INF/container.xml", &container); err != nil {
+		return nil, err
+	}
+	if len(container.Rootfiles) == 0 {
+		return nil, errNoEbookMeta
+	}
+
+	var opf struct {
+		Titles   []string `xml:"metadata>title"`
+		Creators []string `xml:"metadata>creator"`
+	}
+	if err := readZipXML(&r.Reader, path.Clean(container.Rootfiles[0].FullPath), &opf); err != nil {
+		return nil, err
+	}
+
+	meta := &EbookMeta{}
+	if len(opf.Titles) > 0 {
+		meta.Title = strings.TrimSpace(opf.Titles[0])
+	}
+	if len(opf.Creators) > 0 {
+		meta.Author = strings.TrimSpace(opf.Creators[0])
+	}
+	if meta.Title == "" && meta.Author == "" {
+		return nil, errNoEbookMeta
+	}
+	return meta, nil
+}
+
+// readZipXML - decodes one (small) XML file of a zip, namespaces are ignored by encoding/xml
+func readZipXML(r *zip.Reader, name string, v any) error {
+	for _, f := range r.File {
+		if f.Name != name {
+			continue
+		}
+		if f.UncompressedSize64 > maxOPFSize {
+			return errNoEbookMeta
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		defer func() { _ = rc.Close() }()
+		return xml.NewDecoder(io.LimitReader(rc, maxOPFSize)).Decode(v)
+	}
+	return errNoEbookMeta
+}
+
+// EXTH record types
+const (
+	exthAuthor       = 100
+	exthUpdatedTitle = 503
+)
+
+func readMOBIMeta(filePath string) (*EbookMeta, error) {
+	file, err := os.Open(filepath.Clean(filePath))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	// PalmDB header, record list starts at 78
+	palm := make([]byte, 82)
+	if _, err := io.ReadFull(file, palm); err != nil {
+		return nil, errNoEbookMeta
+	}
+	if string(palm[60:68]) != "BOOKMOBI" {
+		return nil, errNoEbookMeta
+	}
+	record0 := int64(binary.BigEndian.Uint32(palm[78:82]))
+
+	header := make([]byte, 16+232)
+	n, _ := file.ReadAt(header, record0)
+	header = header[:n]
+	if len(header) < 16+116 || string(header[16:20]) != "MOBI" {
+		return nil, errNoEbookMeta
+	}
+	be := binary.BigEndian
+	mobiHeaderLen := int64(be.Uint32(header[20:24]))
+	encoding := be.Uint32(header[28:32])
+	fullNameOffset := int64(be.Uint32(header[84:88]))
+	fullNameLen := int64(be.Uint32(header[88:92]))
+	exthFlags := be.Uint32(header[128:132])
+
+	decode := func(b []byte) string {
+		if encoding == 65001 {
+			return strings.TrimSpace(string(b))
+		}
+		return strings.TrimSpace(latin1(b)) // cp1252, close enough for names
+	}
+
+	meta := &EbookMeta{}
+	if fullNameLen > 0 && fullNameLen < 4096 {
+		name := make([]byte, fullNameLen)
+		if _, err := file.ReadAt(name, record0+fullNameOffset); err == nil {
+			meta.Title = decode(name)
+		}
+	}
+
+	if exthFlags&0x40 != 0 {
+		exthStart := record0 + 16 + mobiHeaderLen
+		exthHead := make([]byte, 12)
+		if _, err := file.ReadAt(exthHead, exthStart); err == nil && string(exthHead[:4]) == "EXTH" {
+			exthLen := int64(be.Uint32(exthHead[4:8]))
+			if exthLen > 12 && exthLen < maxOPFSize {
+				exth := make([]byte, exthLen-12)
+				if _, err := file.ReadAt(exth, exthStart+12); err == nil {
+					count := int(be.Uint32(exthHead[8:12]))
+					for i := 0; i < count && len(exth) >= 8; i++ {
+						recType := be.Uint32(exth[:4])
+						recLen := int(be.Uint32(exth[4:8]))
+						if recLen < 8 || recLen > len(exth) {
+							break
+						}
+						data := bytes.TrimRight(exth[8:recLen], "\x00")
+						switch recType {
+						case exthAuthor:
+							if meta.Author == "" {
+								meta.Author = decode(data)
+							}
+						case exthUpdatedTitle:
+							meta.Title = decode(data)
+						}
+						exth = exth[recLen:]
+					}
+				}
+			}
+		}
+	}
+
+	if meta.Title == "" && meta.Author == "" {
+		return nil, errNoEbookMeta
+	}
+	return meta, nil
+}
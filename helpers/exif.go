@@ -0,0 +1,424 @@
+// Package helpers - exif
+package helpers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ExifData - the few EXIF fields GoSorter cares about
+type ExifData struct {
+	DateTimeOriginal time.Time
+	Make             string
+	Model            string
+}
+
+var errNoExif = errors.New("no EXIF data found")
+
+const (
+	tagMake             = 0x010F
+	tagModel            = 0x0110
+	tagDateTime         = 0x0132
+	tagExifIFDPointer   = 0x8769
+	tagDateTimeOriginal = 0x9003
+	tagDateTimeDigitize = 0x9004
+
+	exifDateLayout = "2006:01:02 15:04:05"
+	maxMetaBoxSize = 4 << 20 // HEIC meta box, should be a few KB
+)
+
+// ReadExif - reads EXIF from JPEG, TIFF or HEIC/HEIF files without decoding the image
+func ReadExif(filePath string) (*ExifData, error) {
+	filePath = filepath.Clean(filePath)
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return nil, errNoExif
+	}
+
+	switch {
+	case header[0] == 0xFF && header[1] == 0xD8:
+		return readJPEGExif(file, info.Size())
+	case string(header[:4]) == "II*\x00" || string(header[:4]) == "MM\x00*":
+		return parseTIFF(io.NewSectionReader(file, 0, info.Size()))
+	case string(header[4:8]) == "ftyp":
+		return readHEIFExif(file, info.Size())
+	}
+	return nil, errNoExif
+}
+
+// walks JPEG markers until the APP1 Exif segment
+func readJPEGExif(r io.ReaderAt, size int64) (*ExifData, error) {
+	offset := int64(2)
+	marker := make([]byte, 4)
+	for offset+4 <= size {
+		if _, err := r.ReadAt(marker, offset); err != nil {
+			return nil, errNoExif
+		}
+		if marker[0] != 0xFF {
+			return nil, errNoExif
+		}
+		// start of scan or end of image, no more metadata
+		if marker[1] == 0xDA || marker[1] == 0xD9 {
+			return nil, errNoExif
+		}
+		segLen := int64(binary.BigEndian.Uint16(marker[2:]))
+		if marker[1] == 0xE1 && segLen > 8 {
+			ident := make([]byte, 6)
+			if _, err := r.ReadAt(ident, offset+4); err == nil && string(ident) == "Exif\x00\x00" {
+				return parseTIFF(io.NewSectionReader(r, offset+10, segLen-8))
+			}
+		}
+		offset += 2 + segLen
+	}
+	return nil, errNoExif
+}
+
+// HEIC/HEIF keep EXIF as an item in the meta box, located through iinf + iloc
+func readHEIFExif(r io.ReaderAt, size int64) (*ExifData, error) {
+	meta, err := findBox(r, 0, size, "meta")
+	if err != nil {
+		return nil, err
+	}
+	// a full box starts with version + flags, anything shorter is truncated
+	if meta.size < 4 || meta.size > maxMetaBoxSize {
+		return nil, errNoExif
+	}
+	buf := make([]byte, meta.size)
+	if _, err := r.ReadAt(buf, meta.offset); err != nil {
+		return nil, errNoExif
+	}
+	// meta is a full box, skip version + flags
+	children := buf[4:]
+
+	exifID, ok := findExifItemID(childBox(children, "iinf"))
+	if !ok {
+		return nil, errNoExif
+	}
+	itemOffset, itemLength, ok := findItemLocation(childBox(children, "iloc"), exifID)
+	if !ok || itemLength < 4 {
+		return nil, errNoExif
+	}
+
+	// item payload starts with the offset to the TIFF header
+	prefix := make([]byte, 4)
+	if _, err := r.ReadAt(prefix, int64(itemOffset)); err != nil {
+		return nil, errNoExif
+	}
+	skip := int64(binary.BigEndian.Uint32(prefix))
+	start := int64(itemOffset) + 4 + skip
+	return parseTIFF(io.NewSectionReader(r, start, int64(itemLength)-4-skip))
+}
+
+type isoBox struct {
+	offset int64 // payload offset
+	size   int64 // payload size
+}
+
+func findBox(r io.ReaderAt, start, end int64, boxType string) (isoBox, error) {
+	found, ok := isoBox{}, false
+	eachBox(r, start, end, func(t string, b isoBox) bool {
+		if t == boxType {
+			found, ok = b, true
+			return false
+		}
+		return true
+	})
+	if !ok {
+		return isoBox{}, errNoExif
+	}
+	return found, nil
+}
+
+// eachBox - calls fn for every box between start and end until fn returns false
+func eachBox(r io.ReaderAt, start, end int64, fn func(boxType string, b isoBox) bool) {
+	head := make([]byte, 16)
+	for offset := start; offset+8 <= end; {
+		if _, err := r.ReadAt(head[:8], offset); err != nil {
+			return
+		}
+		size := int64(binary.BigEndian.Uint32(head[:4]))
+		headerLen := int64(8)
+		switch size {
+		case 0:
+			size = end - offset
+		case 1:
+			if _, err := r.ReadAt(head[8:16], offset+8); err != nil {
+				return
+			}
+			size = int64(binary.BigEndian.Uint64(head[8:16]))
+			headerLen = 16
+		}
+		if size < headerLen {
+			return
+		}
+		if !fn(string(head[4:8]), isoBox{offset: offset + headerLen, size: size - headerLen}) {
+			return
+		}
+		offset += size
+	}
+}
+
+// childBox - payload of the first child box of the given type in an in-memory container
+func childBox(buf []byte, boxType string) []byte {
+	for len(buf) >= 8 {
+		size := int(binary.BigEndian.Uint32(buf[:4]))
+		headerLen := 8
+		if size == 1 && len(buf) >= 16 {
+			size = int(binary.BigEndian.Uint64(buf[8:16]))
+			headerLen = 16
+		} else if size == 0 {
+			size = len(buf)
+		}
+		if size < headerLen || size > len(buf) {
+			return nil
+		}
+		if string(buf[4:8]) == boxType {
+			return buf[headerLen:size]
+		}
+		buf = buf[size:]
+	}
+	return nil
+}
+
+func findExifItemID(iinf []byte) (uint32, bool) {
+	if len(iinf) < 6 {
+		return 0, false
+	}
+	version := iinf[0]
+	entries := iinf[6:]
+	if version != 0 {
+		if len(iinf) < 8 {
+			return 0, false
+		}
+		entries = iinf[8:]
+	}
+	for len(entries) >= 8 {
+		size := int(binary.BigEndian.Uint32(entries[:4]))
+		if size < 8 || size > len(entries) {
+			return 0, false
+		}
+		if string(entries[4:8]) == "infe" {
+			infe := entries[8:size]
+			if len(infe) >= 4 {
+				infeVersion := infe[0]
+				body := infe[4:]
+				var id uint32
+				switch {
+				case infeVersion == 2 && len(body) >= 8:
+					id = uint32(binary.BigEndian.Uint16(body[:2]))
+					body = body[4:]
+				case infeVersion == 3 && len(body) >= 10:
+					id = binary.BigEndian.Uint32(body[:4])
+					body = body[6:]
+				default:
+					body = nil
+				}
+				if len(body) >= 4 && string(body[:4]) == "Exif" {
+					return id, true
+				}
+			}
+		}
+		entries = entries[size:]
+	}
+	return 0, false
+}
+
+func findItemLocation(iloc []byte, itemID uint32) (uint64, uint64, bool) {
+	if len(iloc) < 8 {
+		return 0, 0, false
+	}
+	version := iloc[0]
+	offsetSize := int(iloc[4] >> 4)
+	lengthSize := int(iloc[4] & 0x0F)
+	baseOffsetSize := int(iloc[5] >> 4)
+	indexSize := 0
+	if version == 1 || version == 2 {
+		indexSize = int(iloc[5] & 0x0F)
+	}
+
+	p := 6
+	readN := func(n int) (uint64, bool) {
+		if n == 0 {
+			return 0, true
+		}
+		if p+n > len(iloc) {
+			return 0, false
+		}
+		var v uint64
+		for _, b := range iloc[p : p+n] {
+			v = v<<8 | uint64(b)
+		}
+		p += n
+		return v, true
+	}
+
+	idSize := 2
+	if version == 2 {
+		idSize = 4
+	}
+	count, ok := readN(idSize)
+	if !ok {
+		return 0, 0, false
+	}
+	for i := uint64(0); i < count; i++ {
+		id, ok := readN(idSize)
+		if !ok {
+			return 0, 0, false
+		}
+		if version == 1 || version == 2 {
+			if _, ok := readN(2); !ok { // construction method
+				return 0, 0, false
+			}
+		}
+		if _, ok := readN(2); !ok { // data reference index
+			return 0, 0, false
+		}
+		base, ok := readN(baseOffsetSize)
+		if !ok {
+			return 0, 0, false
+		}
+		extents, ok := readN(2)
+		if !ok {
+			return 0, 0, false
+		}
+		var firstOffset, firstLength uint64
+		for e := uint64(0); e < extents; e++ {
+			if _, ok := readN(indexSize); !ok {
+				return 0, 0, false
+			}
+			off, ok1 := readN(offsetSize)
+			length, ok2 := readN(lengthSize)
+			if !ok1 || !ok2 {
+				return 0, 0, false
+			}
+			if e == 0 {
+				firstOffset, firstLength = off, length
+			}
+		}
+		if uint32(id) == itemID && extents > 0 {
+			return base + firstOffset, firstLength, true
+		}
+	}
+	return 0, 0, false
+}
+
+type tiffReader struct {
+	r     io.ReaderAt
+	order binary.ByteOrder
+}
+
+func parseTIFF(r io.ReaderAt) (*ExifData, error) {
+	header := make([]byte, 8)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, errNoExif
+	}
+	t := tiffReader{r: r}
+	switch string(header[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return nil, errNoExif
+	}
+
+	data := &ExifData{}
+	var dateTime string
+	exifIFD := uint32(0)
+	err := t.walkIFD(t.order.Uint32(header[4:]), func(tag, typ uint16, count, value uint32, raw []byte) {
+		switch tag {
+		case tagMake:
+			data.Make = t.asciiValue(typ, count, value, raw)
+		case tagModel:
+			data.Model = t.asciiValue(typ, count, value, raw)
+		case tagDateTime:
+			dateTime = t.asciiValue(typ, count, value, raw)
+		case tagExifIFDPointer:
+			exifIFD = value
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var original, digitized string
+	if exifIFD != 0 {
+		_ = t.walkIFD(exifIFD, func(tag, typ uint16, count, value uint32, raw []byte) {
+			switch tag {
+			case tagDateTimeOriginal:
+				original = t.asciiValue(typ, count, value, raw)
+			case tagDateTimeDigitize:
+				digitized = t.asciiValue(typ, count, value, raw)
+			}
+		})
+	}
+
+	for _, s := range []string{original, digitized, dateTime} {
+		if ts, err := time.ParseInLocation(exifDateLayout, s, time.Local); err == nil {
+			data.DateTimeOriginal = ts
+			break
+		}
+	}
+	if data.DateTimeOriginal.IsZero() && data.Make == "" && data.Model == "" {
+		return nil, errNoExif
+	}
+	return data, nil
+}
+
+func (t tiffReader) walkIFD(offset uint32, fn func(tag, typ uint16, count, value uint32, raw []byte)) error {
+	countBuf := make([]byte, 2)
+	if _, err := t.r.ReadAt(countBuf, int64(offset)); err != nil {
+		return errNoExif
+	}
+	n := int(t.order.Uint16(countBuf))
+	entries := make([]byte, n*12)
+	if _, err := t.r.ReadAt(entries, int64(offset)+2); err != nil {
+		return errNoExif
+	}
+	for i := 0; i < n; i++ {
+		e := entries[i*12 : i*12+12]
+		typ := t.order.Uint16(e[2:])
+		value := t.order.Uint32(e[8:])
+		if typ == 3 { // SHORT, left-justified in the value field
+			value = uint32(t.order.Uint16(e[8:]))
+		}
+		fn(t.order.Uint16(e[0:]), typ, t.order.Uint32(e[4:]), value, e[8:12])
+	}
+	return nil
+}
+
+func (t tiffReader) asciiValue(typ uint16, count, value uint32, raw []byte) string {
+	if typ != 2 || count == 0 || count > 1024 {
+		return ""
+	}
+	var buf []byte
+	if count <= 4 {
+		buf = raw[:count]
+	} else {
+		buf = make([]byte, count)
+		if _, err := t.r.ReadAt(buf, int64(value)); err != nil {
+			return ""
+		}
+	}
+	if i := bytes.IndexByte(buf, 0); i >= 0 {
+		buf = buf[:i]
+	}
+	return strings.TrimSpace(string(buf))
+}
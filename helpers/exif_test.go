@@ -0,0 +1,33 @@
+// Package helpers - exif tests
+package helpers
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadExif_TruncatedHEIFMeta(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_exif")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	// 16-byte ftyp box, then a meta box with only its 8-byte header and no version + flags
+	data := []byte("\x00\x00\x00\x10ftypheic\x00\x00\x00\x00")
+	data = append(data, []byte("\x00\x00\x00\x08meta")...)
+	path := filepath.Join(tempDir, "truncated.heic")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	if _, err := ReadExif(path); !errors.Is(err, errNoExif) {
+		t.Errorf("Expected errNoExif, got %v", err)
+	}
+}
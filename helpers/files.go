@@ -2,11 +2,15 @@
 package helpers
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/mohamedation/GoSorter/model"
 )
@@ -16,13 +20,93 @@ func FolderExists(folderPath string) bool {
 	return !os.IsNotExist(err)
 }
 
-func MoveFile(src, dst string, cfg model.Config, logger Logger) error {
-	if err := os.Rename(src, dst); err == nil {
+// TargetPath - target folder inside folderPath, absolute folders (allow_outside_folders) are used as they are
+func TargetPath(folderPath, targetFolder string) string {
+	if filepath.IsAbs(targetFolder) {
+		return filepath.Clean(targetFolder)
+	}
+	return filepath.Join(folderPath, targetFolder)
+}
+
+// MoveFile - renames src to dst, copies when the rename is not possible (another filesystem).
+// a cancelled or failed copy leaves the source where it was and no partial destination
+func MoveFile(ctx context.Context, src, dst string, cfg model.Config, logger Logger) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	err := os.Rename(src, dst)
+	if err == nil {
 		return nil
-	} else if !os.IsExist(err) && !os.IsPermission(err) {
+	}
+	if !isCrossDevice(err) && !os.IsExist(err) && !os.IsPermission(err) {
+		return err
+	}
+	if info, lerr := os.Lstat(src); lerr == nil && info.Mode()&os.ModeSymlink != 0 {
+		// copying would replace the link with a copy of its target
+		logger.Log(cfg, Debug, fmt.Sprintf("Rename not possible (%v), recreating link %s\n", err, FormatPath(src, cfg)))
+		return moveSymlink(src, dst)
+	}
+	logger.Log(cfg, Debug, fmt.Sprintf("Rename not possible (%v), copying %s\n", err, FormatPath(src, cfg)))
+	return copyAndRemove(ctx, filepath.Clean(src), filepath.Clean(dst), cfg, logger)
+}
+
+// moveSymlink - creates the same link next to dst and renames it into place, then removes src
+func moveSymlink(src, dst string) error {
+	target, err := os.Readlink(src)
+	if err != nil {
+		return err
+	}
+	if err := replaceSymlink(target, dst); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
+// RetargetSymlink - a relative symlink moved out of oldDir is changed to point to the same
+// file from its new place. other files and absolute links are left as they are
+func RetargetSymlink(link, oldDir string) error {
+	info, err := os.Lstat(link)
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		return err
+	}
+	target, err := os.Readlink(link)
+	if err != nil || filepath.IsAbs(target) {
+		return err
+	}
+	oldDir, err = filepath.Abs(oldDir)
+	if err != nil {
+		return err
+	}
+	newDir, err := filepath.Abs(filepath.Dir(link))
+	if err != nil {
 		return err
 	}
-	src = filepath.Clean(src)
+	newTarget, err := filepath.Rel(newDir, filepath.Join(oldDir, target))
+	if err != nil || newTarget == target {
+		return err
+	}
+	return replaceSymlink(newTarget, link)
+}
+
+// replaceSymlink - creates the link under a temporary name and renames it to path
+func replaceSymlink(target, path string) error {
+	tmp := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".gosorter-link")
+	_ = os.Remove(tmp)
+	if err := os.Symlink(target, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// copyAndRemove - copies into a temporary file next to dst, syncs it, checks it against the
+// source when cfg.VerifyCopies is set and carries over mode, owner, extended attributes and
+// times. only then is it renamed to dst and the source removed, so an interruption at any
+// point leaves the source intact and dst either missing or complete
+func copyAndRemove(ctx context.Context, src, dst string, cfg model.Config, logger Logger) error {
 	srcFile, err := os.Open(src)
 	if err != nil {
 		return err
@@ -32,24 +116,112 @@ func MoveFile(src, dst string, cfg model.Config, logger Logger) error {
 			logger.Log(cfg, Error, fmt.Sprintf("error closing srcFile: %v", err))
 		}
 	}()
-	dst = filepath.Clean(dst)
-	dstFile, err := os.Create(dst)
+	info, err := srcFile.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".gosorter-*")
 	if err != nil {
 		return err
 	}
+	tmpPath := tmp.Name()
+	renamed := false
 	defer func() {
-		if err := dstFile.Close(); err != nil {
-			logger.Log(cfg, Error, fmt.Sprintf("error closing dstFile: %v", err))
+		if renamed {
+			return
+		}
+		_ = tmp.Close()
+		if err := os.Remove(tmpPath); err != nil && !os.IsNotExist(err) {
+			logger.Log(cfg, Error, fmt.Sprintf("Failed to remove partial copy %s: %v\n", tmpPath, err))
 		}
 	}()
 
-	if _, err := io.Copy(dstFile, srcFile); err != nil {
+	var reader io.Reader = &contextReader{ctx: ctx, r: srcFile}
+	srcHash := sha256.New()
+	if cfg.VerifyCopies {
+		reader = io.TeeReader(reader, srcHash)
+	}
+	if _, err := io.Copy(tmp, reader); err != nil {
 		return err
 	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if cfg.VerifyCopies {
+		copyHash, err := hashReader(ctx, tmpPath)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(copyHash, srcHash.Sum(nil)) {
+			return fmt.Errorf("copy of %s does not match the original", src)
+		}
+	}
+
+	// owner first, chown clears setuid/setgid. times last, the other changes may touch them
+	if err := copyOwner(info, tmpPath); err != nil {
+		logger.Log(cfg, Debug, fmt.Sprintf("Could not keep owner of %s: %v\n", FormatPath(src, cfg), err))
+	}
+	if err := os.Chmod(tmpPath, info.Mode()&(os.ModePerm|os.ModeSetuid|os.ModeSetgid|os.ModeSticky)); err != nil {
+		logger.Log(cfg, Debug, fmt.Sprintf("Could not keep permissions of %s: %v\n", FormatPath(src, cfg), err))
+	}
+	if err := copyXattrs(src, tmpPath); err != nil {
+		logger.Log(cfg, Debug, fmt.Sprintf("Could not keep extended attributes of %s: %v\n", FormatPath(src, cfg), err))
+	}
+	if err := os.Chtimes(tmpPath, accessTime(info), info.ModTime()); err != nil {
+		logger.Log(cfg, Debug, fmt.Sprintf("Could not keep times of %s: %v\n", FormatPath(src, cfg), err))
+	}
+
+	if err := os.Rename(tmpPath, dst); err != nil {
+		return err
+	}
+	renamed = true
+	syncDir(filepath.Dir(dst))
 
 	return os.Remove(src)
 }
 
+// hashReader - sha256 of a file, read again from disk
+func hashReader(ctx context.Context, path string) ([]byte, error) {
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, &contextReader{ctx: ctx, r: file}); err != nil {
+		return nil, err
+	}
+	return hash.Sum(nil), nil
+}
+
+// syncDir - makes the rename durable, directories cannot be synced everywhere (windows)
+func syncDir(dir string) {
+	d, err := os.Open(filepath.Clean(dir))
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
+}
+
+// contextReader - stops a copy between reads once ctx is cancelled
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr *contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
+
 func FormatPath(path string, cfg model.Config) string {
 	dir := filepath.Dir(path)
 	base := filepath.Base(path)
@@ -69,12 +241,15 @@ func FormatPath(path string, cfg model.Config) string {
 	return fmt.Sprintf("%s...%s%s", name[:3], name[len(name)-4:], ext)
 }
 
-func MoveDuplicateFile(folderPath, fileName, originalPath string, cfg model.Config, logger Logger) {
-	duplicatesFolder := filepath.Join(folderPath, "Duplicates")
+// MoveDuplicateFile - moves fileName to the duplicates folder (duplicates_folder), returns the
+// destination path, empty if the duplicate was not moved. a duplicate of the same name from an
+// earlier run is kept, the new one gets a number like a name conflict
+func MoveDuplicateFile(ctx context.Context, folderPath, fileName, originalPath, duplicatesFolder string, cfg model.Config, logger Logger) string {
+	duplicatesFolder = TargetPath(folderPath, duplicatesFolder)
 	if !FolderExists(duplicatesFolder) {
 		if err := os.MkdirAll(duplicatesFolder, 0750); err != nil {
 			logger.Log(cfg, Error, fmt.Sprintf("Failed to create folder %s: %v\n", duplicatesFolder, err))
-			return
+			return ""
 		}
 	}
 
@@ -82,33 +257,67 @@ func MoveDuplicateFile(folderPath, fileName, originalPath string, cfg model.Conf
 	duplicateFileName := strings.TrimSuffix(fileName, filepath.Ext(fileName))
 	newDuplicateFileName := fmt.Sprintf("%s_duplicate_of_%s%s", duplicateFileName, originalFileName, filepath.Ext(fileName))
 	duplicateDstPath := filepath.Join(duplicatesFolder, newDuplicateFileName)
+	if FileExists(duplicateDstPath) {
+		duplicateDstPath = conflictPath(duplicatesFolder, newDuplicateFileName, "")
+	}
 	srcPath := filepath.Join(folderPath, fileName)
 
-	if err := MoveFile(srcPath, duplicateDstPath, cfg, logger); err != nil {
+	if err := MoveFile(ctx, srcPath, duplicateDstPath, cfg, logger); err != nil {
 		logger.Log(cfg, Error, fmt.Sprintf("Failed to move duplicate file %s: %v\n", srcPath, err))
-		return
+		return ""
 	}
 
 	logger.Log(cfg, Info, fmt.Sprintf("Moved duplicate: %s -> %s\n", FormatPath(srcPath, cfg), FormatPath(duplicateDstPath, cfg)))
+	return duplicateDstPath
 }
 
-func MoveExtractedArchive(folderPath, fileName string, cfg model.Config, logger Logger) {
-	extractedFolder := filepath.Join(folderPath, "Archives-Extracted")
+// MoveExtractedArchive - moves fileName to the extracted archives folder (archives_extracted_folder),
+// returns the destination path, empty if the archive was not moved
+func MoveExtractedArchive(ctx context.Context, folderPath, fileName, extractedFolder string, cfg model.Config, logger Logger) string {
+	extractedFolder = TargetPath(folderPath, extractedFolder)
 	if !FolderExists(extractedFolder) {
 		if err := os.MkdirAll(extractedFolder, 0750); err != nil {
 			logger.Log(cfg, Error, fmt.Sprintf("Failed to create folder %s: %v\n", extractedFolder, err))
-			return
+			return ""
 		}
 	}
 
 	extractedDstPath := filepath.Join(extractedFolder, fileName)
+	if FileExists(extractedDstPath) {
+		extractedDstPath = conflictPath(extractedFolder, fileName, "")
+	}
 	srcPath := filepath.Join(folderPath, fileName)
-	if err := MoveFile(srcPath, extractedDstPath, cfg, logger); err != nil {
+	if err := MoveFile(ctx, srcPath, extractedDstPath, cfg, logger); err != nil {
 		logger.Log(cfg, Error, fmt.Sprintf("Failed to move archive %s: %v\n", srcPath, err))
-		return
+		return ""
 	}
 
 	logger.Log(cfg, Info, fmt.Sprintf("Moved: %s -> %s\n", FormatPath(srcPath, cfg), FormatPath(extractedDstPath, cfg)))
+	return extractedDstPath
+}
+
+// SanitizeName - makes metadata (camera model, artist...) safe to use as a folder or file name
+func SanitizeName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r < 0x20 || r == 0x7F:
+			return -1
+		case strings.ContainsRune(`/\:*?"<>|`, r):
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.Join(strings.Fields(name), " ")
+	name = strings.Trim(name, " .")
+	if len(name) > 128 {
+		// cut at a rune boundary, non-ASCII tags would otherwise end in half a character
+		cut := 128
+		for cut > 0 && !utf8.RuneStart(name[cut]) {
+			cut--
+		}
+		name = strings.Trim(name[:cut], " .")
+	}
+	return name
 }
 
 func FileExists(path string) bool {
@@ -116,63 +325,16 @@ func FileExists(path string) bool {
 	return err == nil && !info.IsDir()
 }
 
-func MoveFileToTargetFolder(folderPath, fileName, targetFolder string, cfg model.Config, logger Logger) {
-	targetPath := filepath.Join(folderPath, targetFolder)
-	if !FolderExists(targetPath) {
-		if err := os.MkdirAll(targetPath, 0750); err != nil {
-			logger.Log(cfg, Error, fmt.Sprintf("Failed to create folder %s: %v\n", targetPath, err))
-			return
-		}
-	}
-
-	srcPath := filepath.Join(folderPath, fileName)
-	dstPath := filepath.Join(targetPath, fileName)
-
-	if FileExists(dstPath) {
-		maxBytes := cfg.MaxHashFileSizeMB
-		if maxBytes <= 0 {
-			maxBytes = 1024
-		}
-		maxBytes = maxBytes * 1024 * 1024 // MB to bytes
-
-		logger.Log(cfg, Debug, fmt.Sprintf("Hashing source file: %s\n", FormatPath(srcPath, cfg)))
-		srcHash, err := HashFile(srcPath, maxBytes, cfg, logger)
-		if err != nil {
-			logger.Log(cfg, Error, fmt.Sprintf("Failed to hash source file %s: %v\n", srcPath, err))
-			return
-		}
-		logger.Log(cfg, Debug, fmt.Sprintf("Hashing destination file: %s\n", FormatPath(dstPath, cfg)))
-		dstHash, err := HashFile(dstPath, maxBytes, cfg, logger)
-		if err != nil {
-			logger.Log(cfg, Error, fmt.Sprintf("Failed to hash destination file %s: %v\n", dstPath, err))
-			return
-		}
-		if srcHash != dstHash {
-			ext := filepath.Ext(fileName)
-			name := strings.TrimSuffix(fileName, ext)
-			i := 1
-			for {
-				newName := fmt.Sprintf("%s(%d)%s", name, i, ext)
-				newDstPath := filepath.Join(targetPath, newName)
-				if !FileExists(newDstPath) {
-					dstPath = newDstPath
-					break
-				}
-				i++
-			}
-			logger.Log(cfg, Debug, fmt.Sprintf("File conflict: %s exists, renaming to %s\n", FormatPath(dstPath, cfg), FormatPath(dstPath, cfg)))
-		} else {
-			if err := os.Remove(dstPath); err != nil {
-				logger.Log(cfg, Error, fmt.Sprintf("Failed to overwrite file %s: %v\n", dstPath, err))
-				return
-			}
-			logger.Log(cfg, Debug, fmt.Sprintf("Overwriting file: %s\n", FormatPath(dstPath, cfg)))
-		}
-	}
+func MoveFileToTargetFolder(ctx context.Context, folderPath, fileName, targetFolder string, cfg model.Config, logger Logger) string {
+	return MoveFileToTargetFolderAs(ctx, folderPath, fileName, targetFolder, fileName, cfg, logger)
+}
 
-	if err := MoveFile(srcPath, dstPath, cfg, logger); err != nil {
-		logger.Log(cfg, Error, fmt.Sprintf("Failed to move file %s: %v\n", srcPath, err))
-		return
+// MoveFileToTargetFolderAs - same as MoveFileToTargetFolder, but renames the file to newName.
+// returns the destination path after conflict renames, empty if the file was not moved
+func MoveFileToTargetFolderAs(ctx context.Context, folderPath, fileName, targetFolder, newName string, cfg model.Config, logger Logger) string {
+	dstPath, outcome := PlaceFile(ctx, folderPath, fileName, targetFolder, newName, model.Conflict{Policy: model.ConflictRename}, cfg, logger)
+	if outcome != Placed {
+		return ""
 	}
-	logger.Log(cfg, Info, fmt.Sprintf("Moved: %s -> %s\n", FormatPath(srcPath, cfg), FormatPath(dstPath, cfg)))
+	return dstPath
 }
@@ -0,0 +1,23 @@
+// Package helpers - file name tests
+package helpers
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSanitizeName_LongNonASCII(t *testing.T) {
+	// 3-byte runes, 128 bytes falls in the middle of the 43rd one
+	name := SanitizeName(strings.Repeat("東京", 50))
+	if !utf8.ValidString(name) {
+		t.Fatalf("Expected valid UTF-8, got %q", name)
+	}
+	if len(name) != 126 {
+		t.Errorf("Expected 42 whole runes (126 bytes), got %d bytes", len(name))
+	}
+
+	if got := SanitizeName("Ünïcödé: " + strings.Repeat("é", 100)); !utf8.ValidString(got) || len(got) > 128 {
+		t.Errorf("Expected valid UTF-8 of at most 128 bytes, got %q", got)
+	}
+}
@@ -0,0 +1,28 @@
+//go:build darwin
+
+// Package helpers - file times (macOS)
+package helpers
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// creationTime - birth time as shown in Finder
+func creationTime(info os.FileInfo) time.Time {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}
+	}
+	return time.Unix(st.Birthtimespec.Sec, st.Birthtimespec.Nsec)
+}
+
+// accessTime - last access time, kept when a file is copied between filesystems
+func accessTime(info os.FileInfo) time.Time {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}
+	}
+	return time.Unix(st.Atimespec.Sec, st.Atimespec.Nsec)
+}
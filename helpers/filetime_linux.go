@@ -0,0 +1,28 @@
+//go:build linux
+
+// Package helpers - file times (linux)
+package helpers
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// creationTime - linux has no portable birth time in stat, use the inode change time
+func creationTime(info os.FileInfo) time.Time {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}
+	}
+	return time.Unix(st.Ctim.Sec, st.Ctim.Nsec)
+}
+
+// accessTime - last access time, kept when a file is copied between filesystems
+func accessTime(info os.FileInfo) time.Time {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}
+	}
+	return time.Unix(st.Atim.Sec, st.Atim.Nsec)
+}
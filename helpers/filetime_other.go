@@ -0,0 +1,19 @@
+//go:build !linux && !darwin && !windows
+
+// Package helpers - file times (other platforms)
+package helpers
+
+import (
+	"os"
+	"time"
+)
+
+// creationTime - not available, callers fall back to mtime
+func creationTime(_ os.FileInfo) time.Time {
+	return time.Time{}
+}
+
+// accessTime - not available, a zero time leaves the copy's access time as it is
+func accessTime(_ os.FileInfo) time.Time {
+	return time.Time{}
+}
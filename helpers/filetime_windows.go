@@ -0,0 +1,28 @@
+//go:build windows
+
+// Package helpers - file times (windows)
+package helpers
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// creationTime - creation time as shown in Explorer
+func creationTime(info os.FileInfo) time.Time {
+	attr, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return time.Time{}
+	}
+	return time.Unix(0, attr.CreationTime.Nanoseconds())
+}
+
+// accessTime - last access time, kept when a file is copied between drives
+func accessTime(info os.FileInfo) time.Time {
+	attr, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return time.Time{}
+	}
+	return time.Unix(0, attr.LastAccessTime.Nanoseconds())
+}
@@ -2,6 +2,7 @@
 package helpers
 
 import (
+	"context"
 	"crypto/sha256"
 	"fmt"
 	"io"
@@ -11,8 +12,8 @@ import (
 	"github.com/mohamedation/GoSorter/model"
 )
 
-// HashFile - size limit is for speed
-func HashFile(filePath string, maxBytes int64, cfg model.Config, logger Logger) (string, error) {
+// HashFile - size limit is for speed, stops between reads once ctx is cancelled
+func HashFile(ctx context.Context, filePath string, maxBytes int64, cfg model.Config, logger Logger) (string, error) {
 	if logger != nil {
 		logger.Log(cfg, Debug, fmt.Sprintf("[DEBUG] Opening file for hashing: %s", filePath))
 	}
@@ -44,8 +45,8 @@ func HashFile(filePath string, maxBytes int64, cfg model.Config, logger Logger)
 	}()
 
 	hash := sha256.New()
-	if _, err := io.Copy(hash, file); err != nil {
-		if logger != nil {
+	if _, err := io.Copy(hash, &contextReader{ctx: ctx, r: file}); err != nil {
+		if logger != nil && ctx.Err() == nil {
 			logger.Log(cfg, Error, fmt.Sprintf("[ERROR] Failed to hash file %s: %v", filePath, err))
 		}
 		return "", model.NewHashError(filePath, err)
@@ -64,7 +65,7 @@ func HashFile(filePath string, maxBytes int64, cfg model.Config, logger Logger)
 }
 
 // PartialHashFile - need to test if its accurate enough and if it actually saves time or just adds an extra step
-func PartialHashFile(filePath string, numBytes int64, cfg model.Config, logger Logger) (string, error) {
+func PartialHashFile(ctx context.Context, filePath string, numBytes int64, cfg model.Config, logger Logger) (string, error) {
 	if logger != nil {
 		logger.Log(cfg, Debug, fmt.Sprintf("[DEBUG] Starting partial hash for file: %s (first %d bytes)", filePath, numBytes))
 	}
@@ -83,9 +84,9 @@ func PartialHashFile(filePath string, numBytes int64, cfg model.Config, logger L
 	}()
 
 	buf := make([]byte, numBytes)
-	n, err := file.Read(buf)
+	n, err := (&contextReader{ctx: ctx, r: file}).Read(buf)
 	if err != nil && err != io.EOF {
-		if logger != nil {
+		if logger != nil && ctx.Err() == nil {
 			logger.Log(cfg, Error, fmt.Sprintf("[ERROR] Error reading file for partial hash: %s, error: %v", filePath, err))
 		}
 		return "", err
@@ -0,0 +1,42 @@
+// Package helpers - hashing tests
+package helpers
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mohamedation/GoSorter/model"
+)
+
+func TestHashFile_Cancelled(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_hash")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+	path := filepath.Join(tempDir, "file.bin")
+	if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cfg := model.Config{Silent: true}
+	if _, err := HashFile(ctx, path, 1024, cfg, &CLILogger{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected HashFile to stop with context.Canceled, got %v", err)
+	}
+	if _, err := PartialHashFile(ctx, path, 1024, cfg, &CLILogger{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected PartialHashFile to stop with context.Canceled, got %v", err)
+	}
+
+	if hash, err := HashFile(context.Background(), path, 1024, cfg, &CLILogger{}); err != nil || hash == "" {
+		t.Errorf("Expected a hash without cancellation, got %q, %v", hash, err)
+	}
+}
@@ -2,14 +2,28 @@
 package helpers
 
 import (
-	"image/png"
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // decoders for image.Decode
+	_ "image/png"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/mohamedation/GoSorter/model"
+	_ "golang.org/x/image/webp"
 )
 
-// HasTransparency - maybe also set a size or resolution limit?
+// images above this are not decoded for the transparency check (~64 megapixels, 256MB as NRGBA)
+const maxTransparencyPixels = 64 << 20
+
+// HasTransparency - PNG, GIF and WebP. true when more than cfg.TransparencyThreshold of the pixels
+// are not fully opaque. images without an alpha channel are answered from their header.
 func HasTransparency(filePath string, cfg model.Config, logger Logger) (bool, error) {
 	filePath = filepath.Clean(filePath)
 	file, err := os.Open(filePath)
@@ -25,31 +39,203 @@ func HasTransparency(filePath string, cfg model.Config, logger Logger) (bool, er
 		}
 	}()
 
-	img, err := png.Decode(file)
+	var mayHaveAlpha bool
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".png":
+		mayHaveAlpha, err = pngMayHaveAlpha(file)
+	case ".webp":
+		mayHaveAlpha, err = webpMayHaveAlpha(file)
+	default:
+		// GIF transparency lives in per-frame extensions, decode to find out
+		mayHaveAlpha = true
+	}
 	if err != nil {
 		if logger != nil {
-			logger.Log(cfg, Error, "Failed to decode PNG: "+err.Error())
+			logger.Log(cfg, Error, "Failed to read image header: "+err.Error())
 		}
 		return false, err
 	}
+	if !mayHaveAlpha {
+		return false, nil
+	}
 
-	bounds := img.Bounds()
-	width := bounds.Max.X - bounds.Min.X
-	height := bounds.Max.Y - bounds.Min.Y
-	totalPixels := width * height
-	if totalPixels == 0 {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return false, err
+	}
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		if logger != nil {
+			logger.Log(cfg, Error, "Failed to decode image header: "+err.Error())
+		}
+		return false, err
+	}
+	if int64(config.Width)*int64(config.Height) > maxTransparencyPixels {
+		if logger != nil {
+			logger.Log(cfg, Debug, fmt.Sprintf("Skipping transparency check for %s: %dx%d is too large\n", FormatPath(filePath, cfg), config.Width, config.Height))
+		}
 		return false, nil
 	}
 
-	transparentPixels := 0
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return false, err
+	}
+	img, _, err := image.Decode(bufio.NewReader(file))
+	if err != nil {
+		if logger != nil {
+			logger.Log(cfg, Error, "Failed to decode image: "+err.Error())
+		}
+		return false, err
+	}
+
+	threshold := cfg.TransparencyThreshold
+	if threshold <= 0 {
+		threshold = model.DefaultTransparencyThreshold
+	}
+	return mostlyTransparent(img, threshold), nil
+}
+
+// pngMayHaveAlpha - color types with alpha, or a tRNS chunk before the image data
+func pngMayHaveAlpha(r io.Reader) (bool, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, 8+8+13)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return false, err
+	}
+	if !bytes.Equal(header[:8], []byte("\x89PNG\r\n\x1a\n")) || string(header[12:16]) != "IHDR" {
+		return false, fmt.Errorf("not a PNG file")
+	}
+	const (
+		grayAlpha      = 4
+		truecolorAlpha = 6
+	)
+	if colorType := header[16+9]; colorType == grayAlpha || colorType == truecolorAlpha {
+		return true, nil
+	}
+	// IHDR CRC, then chunks up to IDAT
+	if _, err := br.Discard(4); err != nil {
+		return false, err
+	}
+	chunk := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(br, chunk); err != nil {
+			return false, err
+		}
+		switch string(chunk[4:8]) {
+		case "tRNS":
+			return true, nil
+		case "IDAT", "IEND":
+			return false, nil
+		}
+		if _, err := br.Discard(int(binary.BigEndian.Uint32(chunk[:4])) + 4); err != nil {
+			return false, err
+		}
+	}
+}
+
+// webpMayHaveAlpha - VP8X alpha flag or VP8L alpha_is_used, simple lossy (VP8) has no alpha
+func webpMayHaveAlpha(r io.Reader) (bool, error) {
+	header := make([]byte, 12+8+5)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return false, err
+	}
+	if string(header[:4]) != "RIFF" || string(header[8:12]) != "WEBP" {
+		return false, fmt.Errorf("not a WebP file")
+	}
+	switch string(header[12:16]) {
+	case "VP8X":
+		return header[20]&0x10 != 0, nil
+	case "VP8L":
+		// signature byte, then 14 bit width, 14 bit height, 1 bit alpha_is_used
+		bits := binary.LittleEndian.Uint32(header[21:25])
+		return bits&(1<<28) != 0, nil
+	}
+	return false, nil
+}
+
+// mostlyTransparent - scans raw pixel buffers where possible, stops as soon as the answer is known
+func mostlyTransparent(img image.Image, threshold float64) bool {
+	bounds := img.Bounds()
+	total := bounds.Dx() * bounds.Dy()
+	if total == 0 {
+		return false
+	}
+	// more than limit transparent pixels answers yes, total-limit opaque ones answer no
+	limit := int(threshold * float64(total))
+	transparent, opaque := 0, 0
+	count := func(isTransparent bool) (bool, bool) {
+		if isTransparent {
+			transparent++
+		} else {
+			opaque++
+		}
+		if transparent > limit {
+			return true, true
+		}
+		if opaque >= total-limit {
+			return false, true
+		}
+		return false, false
+	}
+
+	// scan - alpha samples of alphaBytes bytes every step bytes, starting at offset in each row
+	scan := func(pix []byte, stride, rowBytes, offset, step, alphaBytes int) bool {
+		for y := 0; y < bounds.Dy(); y++ {
+			row := pix[y*stride : y*stride+rowBytes]
+			for i := offset; i < len(row); i += step {
+				seeThrough := row[i] != 0xff || (alphaBytes == 2 && row[i+1] != 0xff)
+				if result, done := count(seeThrough); done {
+					return result
+				}
+			}
+		}
+		return false
+	}
+
+	switch m := img.(type) {
+	case *image.Gray, *image.Gray16, *image.YCbCr, *image.CMYK:
+		return false
+	case *image.NRGBA:
+		return scan(m.Pix, m.Stride, bounds.Dx()*4, 3, 4, 1)
+	case *image.RGBA:
+		return scan(m.Pix, m.Stride, bounds.Dx()*4, 3, 4, 1)
+	case *image.NRGBA64:
+		return scan(m.Pix, m.Stride, bounds.Dx()*8, 6, 8, 2)
+	case *image.RGBA64:
+		return scan(m.Pix, m.Stride, bounds.Dx()*8, 6, 8, 2)
+	case *image.NYCbCrA:
+		return scan(m.A, m.AStride, bounds.Dx(), 0, 1, 1)
+	case *image.Paletted:
+		seeThrough := make([]bool, 256)
+		hasAlpha := false
+		for i, c := range m.Palette {
+			if _, _, _, a := c.RGBA(); a < 0xffff {
+				seeThrough[i] = true
+				hasAlpha = true
+			}
+		}
+		if !hasAlpha {
+			return false
+		}
+		for y := 0; y < bounds.Dy(); y++ {
+			for _, idx := range m.Pix[y*m.Stride : y*m.Stride+bounds.Dx()] {
+				if result, done := count(seeThrough[idx]); done {
+					return result
+				}
+			}
+		}
+		return false
+	}
+
+	if cm := img.ColorModel(); cm == color.GrayModel || cm == color.Gray16Model || cm == color.YCbCrModel || cm == color.CMYKModel {
+		return false
+	}
 	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
 		for x := bounds.Min.X; x < bounds.Max.X; x++ {
 			_, _, _, a := img.At(x, y).RGBA()
-			if a < 0xffff {
-				transparentPixels++
+			if result, done := count(a < 0xffff); done {
+				return result
 			}
 		}
 	}
-	// transparency is significant?
-	return float64(transparentPixels)/float64(totalPixels) > 0.5, nil
+	return false
 }
@@ -2,6 +2,7 @@
 package helpers
 
 import (
+	"context"
 	"os"
 
 	"github.com/mohamedation/GoSorter/model"
@@ -10,8 +11,8 @@ import (
 type FileOperations interface {
 	Exists(path string) bool
 	FolderExists(path string) bool
-	MoveFile(src, dst string, cfg model.Config, logger Logger) error
-	HashFile(path string, cfg model.Config, logger Logger) (string, error)
+	MoveFile(ctx context.Context, src, dst string, cfg model.Config, logger Logger) error
+	HashFile(ctx context.Context, path string, cfg model.Config, logger Logger) (string, error)
 	CreateFolder(path string) error
 }
 
@@ -29,12 +30,12 @@ func (o *OSFileOperations) FolderExists(path string) bool {
 	return FolderExists(path)
 }
 
-func (o *OSFileOperations) MoveFile(src, dst string, cfg model.Config, logger Logger) error {
-	return MoveFile(src, dst, cfg, logger)
+func (o *OSFileOperations) MoveFile(ctx context.Context, src, dst string, cfg model.Config, logger Logger) error {
+	return MoveFile(ctx, src, dst, cfg, logger)
 }
 
-func (o *OSFileOperations) HashFile(path string, cfg model.Config, logger Logger) (string, error) {
-	return HashFile(path, 1024*1024*1024, cfg, logger)
+func (o *OSFileOperations) HashFile(ctx context.Context, path string, cfg model.Config, logger Logger) (string, error) {
+	return HashFile(ctx, path, 1024*1024*1024, cfg, logger)
 }
 
 func (o *OSFileOperations) CreateFolder(path string) error {
@@ -42,28 +43,30 @@ func (o *OSFileOperations) CreateFolder(path string) error {
 }
 
 type FileMover struct {
-	fileOps FileOperations
-	config  *model.Config
+	fileOps   FileOperations
+	config    *model.Config
+	extConfig *model.ExtensionConfig
 }
 
 func NewFileMover(fileOps FileOperations, config *model.Config) *FileMover {
 	return &FileMover{
-		fileOps: fileOps,
-		config:  config,
+		fileOps:   fileOps,
+		config:    config,
+		extConfig: model.LoadExtensionConfig(),
 	}
 }
 
-func (fm *FileMover) MoveToTargetFolder(folderPath, fileName, targetFolder string) error {
-	MoveFileToTargetFolder(folderPath, fileName, targetFolder, *fm.config, &CLILogger{})
+func (fm *FileMover) MoveToTargetFolder(ctx context.Context, folderPath, fileName, targetFolder string) error {
+	MoveFileToTargetFolder(ctx, folderPath, fileName, targetFolder, *fm.config, &CLILogger{})
 	return nil
 }
 
-func (fm *FileMover) MoveToDuplicates(folderPath, fileName, originalPath string) error {
-	MoveDuplicateFile(folderPath, fileName, originalPath, *fm.config, &CLILogger{})
+func (fm *FileMover) MoveToDuplicates(ctx context.Context, folderPath, fileName, originalPath string) error {
+	MoveDuplicateFile(ctx, folderPath, fileName, originalPath, fm.extConfig.DuplicatesFolder, *fm.config, &CLILogger{})
 	return nil
 }
 
-func (fm *FileMover) MoveExtractedArchive(folderPath, fileName string) error {
-	MoveExtractedArchive(folderPath, fileName, *fm.config, &CLILogger{})
+func (fm *FileMover) MoveExtractedArchive(ctx context.Context, folderPath, fileName string) error {
+	MoveExtractedArchive(ctx, folderPath, fileName, fm.extConfig.ArchiveExtractedFolder, *fm.config, &CLILogger{})
 	return nil
 }
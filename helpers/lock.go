@@ -0,0 +1,176 @@
+// Package helpers - directory locks
+package helpers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mohamedation/GoSorter/model"
+)
+
+// LockFileName - lock file in the sorted directory, never sorted itself
+const LockFileName = ".gosorter.lock"
+
+// lockStaleAfter - a lock file of another host without flock support is only trusted this long
+const lockStaleAfter = 24 * time.Hour
+
+// errNoFlock - the filesystem has no advisory locks (some network shares), see LockDirectory
+var errNoFlock = errors.New("advisory locks not supported")
+
+// errLockHeld - another process holds the flock
+var errLockHeld = errors.New("lock held")
+
+// LockedError - another GoSorter is working in the directory
+type LockedError struct {
+	Dir    string
+	Holder LockHolder
+}
+
+func (e *LockedError) Error() string {
+	if e.Holder.PID == 0 {
+		return fmt.Sprintf("%s is being sorted by another GoSorter run", e.Dir)
+	}
+	return fmt.Sprintf("%s is being sorted by another GoSorter run (pid %d on %s, since %s)",
+		e.Dir, e.Holder.PID, e.Holder.Host, e.Holder.Started.Format(time.RFC1123))
+}
+
+// LockHolder - written into the lock file, for the error of a second run and stale lock detection
+type LockHolder struct {
+	PID     int       `json:"pid"`
+	Host    string    `json:"host"`
+	Started time.Time `json:"started"`
+}
+
+// DirLock - the lock of one run on a directory
+type DirLock struct {
+	path string
+	file *os.File
+}
+
+// LockDirectory - takes the lock of dir for this run, a *LockedError when another run holds it.
+// the lock is an flock on LockFileName, which the system releases when a run crashes. without
+// flock (some network shares, windows) creating the lock file is the lock, and a lock file whose
+// holder is gone is stale and taken over
+func LockDirectory(dir string, cfg model.Config, logger Logger) (*DirLock, error) {
+	path := filepath.Join(dir, LockFileName)
+	// the lock file can be removed by its holder at any point, retry on the new one
+	for range 3 {
+		created := true
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+		if os.IsExist(err) {
+			created = false
+			f, err = os.OpenFile(path, os.O_RDWR, 0644)
+			if os.IsNotExist(err) {
+				continue
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to create lock file: %w", err)
+		}
+
+		err = lockExclusive(f)
+		switch {
+		case err == nil:
+			if !sameFile(f, path) {
+				_ = f.Close()
+				continue
+			}
+			if holder, err := readLockHolder(path); err == nil {
+				logger.Log(cfg, Debug, fmt.Sprintf("Taking over stale lock of pid %d on %s\n", holder.PID, holder.Host))
+			}
+		case errors.Is(err, errNoFlock) && created:
+			// creating the lock file was the lock
+		case errors.Is(err, errNoFlock):
+			_ = f.Close()
+			holder, err := readLockHolder(path)
+			if !staleLockFile(path, holder, err) {
+				return nil, &LockedError{Dir: dir, Holder: holder}
+			}
+			logger.Log(cfg, Debug, fmt.Sprintf("Removing stale lock file %s\n", path))
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return nil, fmt.Errorf("failed to remove stale lock file: %w", err)
+			}
+			continue
+		case errors.Is(err, errLockHeld):
+			_ = f.Close()
+			holder, _ := readLockHolder(path)
+			return nil, &LockedError{Dir: dir, Holder: holder}
+		default:
+			_ = f.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", dir, err)
+		}
+
+		if err := writeLockHolder(f); err != nil {
+			_ = f.Close()
+			_ = os.Remove(path)
+			return nil, fmt.Errorf("failed to write lock file: %w", err)
+		}
+		return &DirLock{path: path, file: f}, nil
+	}
+	return nil, &LockedError{Dir: dir}
+}
+
+// Unlock - removes the lock file, then releases the lock
+func (l *DirLock) Unlock() error {
+	if l == nil {
+		return nil
+	}
+	err := os.Remove(l.path)
+	if closeErr := l.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// staleLockFile - without flock: the holder is gone (same host) or the lock is older than
+// lockStaleAfter (another host). a lock file without holder is stale once its creator had
+// time to write it
+func staleLockFile(path string, holder LockHolder, readErr error) bool {
+	if readErr != nil {
+		info, err := os.Stat(path)
+		return err != nil || time.Since(info.ModTime()) > time.Minute
+	}
+	if host, err := os.Hostname(); err == nil && host == holder.Host {
+		return !processAlive(holder.PID)
+	}
+	return time.Since(holder.Started) > lockStaleAfter
+}
+
+func readLockHolder(path string) (LockHolder, error) {
+	var holder LockHolder
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return holder, err
+	}
+	err = json.Unmarshal(data, &holder)
+	return holder, err
+}
+
+func writeLockHolder(f *os.File) error {
+	host, _ := os.Hostname()
+	data, err := json.Marshal(LockHolder{PID: os.Getpid(), Host: host, Started: time.Now()})
+	if err != nil {
+		return err
+	}
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := f.WriteAt(append(data, '\n'), 0); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// sameFile - f is still the file at path
+func sameFile(f *os.File, path string) bool {
+	opened, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	current, err := os.Stat(path)
+	return err == nil && os.SameFile(opened, current)
+}
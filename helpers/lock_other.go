@@ -0,0 +1,18 @@
+//go:build !unix || solaris || aix
+
+// Package helpers - directory locks (other platforms)
+package helpers
+
+import "os"
+
+// lockExclusive - no flock (windows, solaris, aix), the lock file is created exclusively instead
+func lockExclusive(_ *os.File) error {
+	return errNoFlock
+}
+
+// processAlive - FindProcess fails for exited processes on windows, elsewhere a process is assumed
+// alive and a stale lock file of the same host has to be removed by hand
+func processAlive(pid int) bool {
+	_, err := os.FindProcess(pid)
+	return err == nil
+}
@@ -0,0 +1,30 @@
+//go:build unix && !solaris && !aix
+
+// Package helpers - directory locks (unix)
+package helpers
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// lockExclusive - non-blocking flock, released by the system when the process exits
+func lockExclusive(f *os.File) error {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, syscall.EWOULDBLOCK):
+		return errLockHeld
+	case errors.Is(err, syscall.ENOLCK), errors.Is(err, syscall.EOPNOTSUPP), errors.Is(err, syscall.ENOSYS):
+		return errNoFlock
+	}
+	return err
+}
+
+// processAlive - signal 0 only checks that the process exists, EPERM means it belongs to another user
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
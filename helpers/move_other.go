@@ -0,0 +1,31 @@
+//go:build !unix && !windows
+
+// Package helpers - moves (other platforms)
+package helpers
+
+import "os"
+
+// isCrossDevice - not detectable, renames that fail are reported as errors
+func isCrossDevice(_ error) bool {
+	return false
+}
+
+// copyOwner - not available
+func copyOwner(_ os.FileInfo, _ string) error {
+	return nil
+}
+
+// FilesystemID - not available, every path counts as the same filesystem
+func FilesystemID(_ string) (string, bool) {
+	return "", false
+}
+
+// Writable - creates and removes a probe file
+func Writable(dir string) error {
+	probe, err := os.CreateTemp(dir, ".gosorter-check-*")
+	if err != nil {
+		return err
+	}
+	_ = probe.Close()
+	return os.Remove(probe.Name())
+}
@@ -0,0 +1,46 @@
+//go:build unix
+
+// Package helpers - moves (unix)
+package helpers
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// isCrossDevice - rename failed because source and destination are on different filesystems
+func isCrossDevice(err error) bool {
+	return errors.Is(err, syscall.EXDEV)
+}
+
+// copyOwner - owner and group of the original, only allowed for root or the file's own group
+func copyOwner(info os.FileInfo, path string) error {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	return os.Lchown(path, int(st.Uid), int(st.Gid))
+}
+
+// FilesystemID - device of the filesystem path is on, symlinks are followed
+func FilesystemID(path string) (string, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", false
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", false
+	}
+	return strconv.FormatUint(uint64(st.Dev), 10), true // the type of Dev differs between platforms
+}
+
+// Writable - the current user may create and remove files in dir. access also reports read-only mounts
+func Writable(dir string) error {
+	if err := syscall.Access(dir, 0x2); err != nil { // W_OK
+		return &os.PathError{Op: "access", Path: dir, Err: err}
+	}
+	return nil
+}
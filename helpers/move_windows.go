@@ -0,0 +1,47 @@
+//go:build windows
+
+// Package helpers - moves (windows)
+package helpers
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// ERROR_NOT_SAME_DEVICE, MoveFileEx cannot move a file to another drive
+const errorNotSameDevice = syscall.Errno(17)
+
+// isCrossDevice - rename failed because source and destination are on different drives
+func isCrossDevice(err error) bool {
+	return errors.Is(err, errorNotSameDevice) || errors.Is(err, syscall.EXDEV)
+}
+
+// copyOwner - the copy is owned by whoever runs GoSorter
+func copyOwner(_ os.FileInfo, _ string) error {
+	return nil
+}
+
+// FilesystemID - drive letter or UNC share of path, after resolving links
+func FilesystemID(path string) (string, bool) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", false
+	}
+	if resolved, err = filepath.Abs(resolved); err != nil {
+		return "", false
+	}
+	return strings.ToUpper(filepath.VolumeName(resolved)), true
+}
+
+// Writable - creates and removes a probe file, permissions on windows are ACLs
+func Writable(dir string) error {
+	probe, err := os.CreateTemp(dir, ".gosorter-check-*")
+	if err != nil {
+		return err
+	}
+	_ = probe.Close()
+	return os.Remove(probe.Name())
+}
@@ -0,0 +1,66 @@
+//go:build linux
+
+// Package helpers - open files (linux)
+package helpers
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// OpenForWriting - names of the files in dir that a process has open for writing, from /proc/*/fd.
+// without root only the processes of the current user are visible
+func OpenForWriting(dir string) (map[string]bool, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	// the fd links point to the resolved path
+	if dir, err = filepath.EvalSymlinks(dir); err != nil {
+		return nil, err
+	}
+	procs, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+
+	open := make(map[string]bool)
+	for _, proc := range procs {
+		if _, err := strconv.Atoi(proc.Name()); err != nil {
+			continue
+		}
+		fdDir := filepath.Join("/proc", proc.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue // exited, or another user's process
+		}
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil || filepath.Dir(target) != dir {
+				continue
+			}
+			if openedForWriting(filepath.Join("/proc", proc.Name(), "fdinfo", fd.Name())) {
+				open[filepath.Base(target)] = true
+			}
+		}
+	}
+	return open, nil
+}
+
+// openedForWriting - the flags line of fdinfo is octal
+func openedForWriting(fdinfo string) bool {
+	data, err := os.ReadFile(fdinfo)
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if value, ok := strings.CutPrefix(line, "flags:"); ok {
+			flags, err := strconv.ParseInt(strings.TrimSpace(value), 8, 64)
+			return err == nil && flags&syscall.O_ACCMODE != syscall.O_RDONLY
+		}
+	}
+	return false
+}
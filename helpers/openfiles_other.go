@@ -0,0 +1,9 @@
+//go:build !linux
+
+// Package helpers - open files (other platforms)
+package helpers
+
+// OpenForWriting - not available, only partial downloads and the quiet period are checked
+func OpenForWriting(_ string) (map[string]bool, error) {
+	return nil, nil
+}
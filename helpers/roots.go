@@ -0,0 +1,140 @@
+// Package helpers - dangerous roots
+package helpers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// projectMarkers - files and folders at the root of a source tree or repository
+var projectMarkers = []string{".git", ".hg", ".svn", "go.mod", "package.json", "Cargo.toml", "pyproject.toml", "pom.xml"}
+
+// DangerousRootError - a directory GoSorter refuses to sort unless forced
+type DangerousRootError struct {
+	Dir    string
+	Reason string
+}
+
+func (e *DangerousRootError) Error() string {
+	return fmt.Sprintf("%s is %s", e.Dir, e.Reason)
+}
+
+// CheckRoot - a *DangerousRootError for directories where sorting does damage: the filesystem
+// root, the home directory itself, system directories, the root of a drive and project or
+// repository roots, where .go and .json files would be scattered into folders
+func CheckRoot(dir string) error {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	// both the given and the resolved path, /etc is /private/etc on macOS
+	paths := []string{filepath.Clean(abs)}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil && resolved != paths[0] {
+		paths = append(paths, resolved)
+	}
+
+	for _, path := range paths {
+		if filepath.Dir(path) == path {
+			return &DangerousRootError{Dir: dir, Reason: "the root of the filesystem"}
+		}
+		if home, err := os.UserHomeDir(); err == nil && samePath(path, filepath.Clean(home)) {
+			return &DangerousRootError{Dir: dir, Reason: "your home directory"}
+		}
+		for _, sys := range systemDirs() {
+			if samePath(path, sys.path) || (sys.tree && isBelow(path, sys.path)) {
+				return &DangerousRootError{Dir: dir, Reason: fmt.Sprintf("the system directory %s", sys.path)}
+			}
+		}
+	}
+
+	if fs, ok := FilesystemID(abs); ok {
+		if parentFS, ok := FilesystemID(filepath.Dir(abs)); ok && parentFS != fs {
+			return &DangerousRootError{Dir: dir, Reason: "the root of a mounted drive"}
+		}
+	}
+	for _, marker := range projectMarkers {
+		if _, err := os.Lstat(filepath.Join(abs, marker)); err == nil {
+			return &DangerousRootError{Dir: dir, Reason: fmt.Sprintf("a project or repository (it contains %s)", marker)}
+		}
+	}
+	return nil
+}
+
+// systemDir - a system directory, with everything below it when tree is set
+type systemDir struct {
+	path string
+	tree bool
+}
+
+func systemDirs() []systemDir {
+	if runtime.GOOS == "windows" {
+		var dirs []systemDir
+		for _, env := range []string{"SystemRoot", "ProgramFiles", "ProgramFiles(x86)", "ProgramData"} {
+			if dir := os.Getenv(env); dir != "" {
+				dirs = append(dirs, systemDir{path: filepath.Clean(dir), tree: true})
+			}
+		}
+		if drive := os.Getenv("SystemDrive"); drive != "" {
+			dirs = append(dirs, systemDir{path: filepath.Join(drive+`\`, "Users")})
+		}
+		return dirs
+	}
+
+	dirs := []systemDir{
+		{path: "/bin", tree: true},
+		{path: "/boot", tree: true},
+		{path: "/dev", tree: true},
+		{path: "/etc", tree: true},
+		{path: "/lib", tree: true},
+		{path: "/lib32", tree: true},
+		{path: "/lib64", tree: true},
+		{path: "/proc", tree: true},
+		{path: "/sbin", tree: true},
+		{path: "/sys", tree: true},
+		{path: "/usr", tree: true},
+		{path: "/home"},
+		{path: "/media"},
+		{path: "/mnt"},
+		{path: "/opt"},
+		{path: "/root"},
+		{path: "/run"}, // removable drives are mounted below /run/media
+		{path: "/srv"},
+		{path: "/tmp"},
+		{path: "/var"},
+	}
+	if runtime.GOOS == "darwin" {
+		dirs = append(dirs,
+			systemDir{path: "/System", tree: true},
+			systemDir{path: "/Library", tree: true},
+			systemDir{path: "/private/etc", tree: true},
+			systemDir{path: "/private/tmp"}, // temporary folders are below /private/var/folders
+			systemDir{path: "/private/var"},
+			systemDir{path: "/Applications"},
+			systemDir{path: "/Users"},
+			systemDir{path: "/Volumes"},
+		)
+	}
+	return dirs
+}
+
+// caseInsensitive - the default filesystems of windows and macOS ignore case
+var caseInsensitive = runtime.GOOS == "windows" || runtime.GOOS == "darwin"
+
+func samePath(a, b string) bool {
+	if caseInsensitive {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// isBelow - path is inside dir
+func isBelow(path, dir string) bool {
+	if caseInsensitive {
+		path, dir = strings.ToLower(path), strings.ToLower(dir)
+	}
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
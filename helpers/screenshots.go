@@ -0,0 +1,81 @@
+// Package helpers - screenshot detection
+package helpers
+
+import (
+	"image"
+	_ "image/jpeg" // DecodeConfig for JPEG screenshots
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// file names used by the usual screenshot tools
+var screenshotNamePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)^screenshot[ _-]`),         // GNOME "Screenshot from ...", KDE/Android "Screenshot_2024...", macOS "Screenshot 2024-...", Windows "Screenshot (3)"
+	regexp.MustCompile(`(?i)^screenshot\.`),            // bare "Screenshot.png"
+	regexp.MustCompile(`(?i)^screen shot \d{4}-\d{2}`), // macOS before Mojave
+	regexp.MustCompile(`(?i)^scr_\d{8}`),               // older Android ROMs
+}
+
+var screenRecordingNamePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)^screen[ _-]?recording`), // macOS "Screen Recording 2024-...", Android "Screen_Recording_2024..."
+	regexp.MustCompile(`(?i)^screencast[ _-]`),       // GNOME "Screencast from ..."
+	regexp.MustCompile(`(?i)^screenrecord`),          // Android screenrecord
+}
+
+// common display and phone resolutions, landscape
+var displayResolutions = map[[2]int]bool{
+	{1024, 768}: true, {1280, 720}: true, {1280, 800}: true, {1280, 1024}: true,
+	{1366, 768}: true, {1440, 900}: true, {1536, 864}: true, {1600, 900}: true,
+	{1680, 1050}: true, {1920, 1080}: true, {1920, 1200}: true, {2560, 1080}: true,
+	{2560, 1440}: true, {2560, 1600}: true, {2880, 1800}: true, {3024, 1964}: true,
+	{3440, 1440}: true, {3456, 2234}: true, {3840, 2160}: true, {5120, 2880}: true,
+	// phones
+	{1136, 640}: true, {1334, 750}: true, {1792, 828}: true, {2208, 1242}: true,
+	{2436, 1125}: true, {2532, 1170}: true, {2556, 1179}: true, {2688, 1242}: true,
+	{2778, 1284}: true, {2796, 1290}: true, {2340, 1080}: true, {2400, 1080}: true,
+	{3120, 1440}: true, {3200, 1440}: true,
+}
+
+// IsScreenshotName - file name follows a screenshot tool's convention
+func IsScreenshotName(fileName string) bool {
+	return matchesAny(screenshotNamePatterns, fileName)
+}
+
+// IsScreenRecordingName - file name follows a screen recorder's convention
+func IsScreenRecordingName(fileName string) bool {
+	return matchesAny(screenRecordingNamePatterns, fileName)
+}
+
+func matchesAny(patterns []*regexp.Regexp, s string) bool {
+	for _, p := range patterns {
+		if p.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDisplayResolution - width x height of a common screen, in either orientation
+func IsDisplayResolution(width, height int) bool {
+	if width < height {
+		width, height = height, width
+	}
+	return displayResolutions[[2]int{width, height}]
+}
+
+// ImageSize - dimensions from the image header, without decoding the pixels
+func ImageSize(filePath string) (int, int, error) {
+	file, err := os.Open(filepath.Clean(filePath))
+	if err != nil {
+		return 0, 0, err
+	}
+	defer func() { _ = file.Close() }()
+
+	cfg, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return 0, 0, err
+	}
+	return cfg.Width, cfg.Height, nil
+}
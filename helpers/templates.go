@@ -0,0 +1,69 @@
+// Package helpers - destination templates
+package helpers
+
+import "strings"
+
+// ExpandTemplate - replaces {token} with values[token], unknown tokens are left as is
+func ExpandTemplate(template string, values map[string]string) string {
+	var b strings.Builder
+	rest := template
+	for {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			break
+		}
+		token := rest[start+1 : start+end]
+		b.WriteString(rest[:start])
+		if v, ok := values[token]; ok {
+			b.WriteString(v)
+		} else {
+			b.WriteString(rest[start : start+end+1])
+		}
+		rest = rest[start+end+1:]
+	}
+	b.WriteString(rest)
+	return b.String()
+}
+
+// ExpandFolderTemplate - ExpandTemplate per path segment, a segment whose tokens expand to
+// nothing ("{name}" of ".pdf") becomes fallback instead of the folder above it
+func ExpandFolderTemplate(template string, values map[string]string, fallback string) string {
+	var b strings.Builder
+	start := 0
+	for i := 0; i <= len(template); i++ {
+		if i < len(template) && template[i] != '/' && template[i] != '\\' {
+			continue
+		}
+		segment := template[start:i]
+		expanded := ExpandTemplate(segment, values)
+		if expanded != segment && strings.Trim(expanded, " .") == "" {
+			expanded = fallback
+		}
+		b.WriteString(expanded)
+		if i < len(template) {
+			b.WriteByte(template[i])
+		}
+		start = i + 1
+	}
+	return b.String()
+}
+
+// SizeBucket - coarse size range usable as a folder name
+func SizeBucket(size int64) string {
+	const mb = 1024 * 1024
+	switch {
+	case size < mb:
+		return "0-1MB"
+	case size < 10*mb:
+		return "1-10MB"
+	case size < 100*mb:
+		return "10-100MB"
+	case size < 1024*mb:
+		return "100MB-1GB"
+	}
+	return "1GB+"
+}
@@ -0,0 +1,22 @@
+// Package helpers - trash
+package helpers
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mohamedation/GoSorter/model"
+)
+
+// Discard - deletes a file GoSorter replaces or removes, or moves it to the Trash with cfg.UseTrash
+func Discard(path string, cfg model.Config, logger Logger) error {
+	if !cfg.UseTrash {
+		return os.Remove(path)
+	}
+	trashed, err := trashFile(path)
+	if err != nil {
+		return fmt.Errorf("moving to the Trash: %w", err)
+	}
+	logger.Log(cfg, Info, fmt.Sprintf("Trashed: %s -> %s\n", FormatPath(path, cfg), FormatPath(trashed, cfg)))
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !freebsd && !openbsd && !netbsd && !dragonfly
+
+// Package helpers - trash (other platforms)
+package helpers
+
+import "errors"
+
+// TrashSupported - -tr works on this platform
+const TrashSupported = false
+
+// trashFile - only the freedesktop.org Trash of Linux and the BSDs is supported
+func trashFile(_ string) (string, error) {
+	return "", errors.New("the Trash is not supported on this platform")
+}
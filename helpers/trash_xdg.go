@@ -0,0 +1,170 @@
+//go:build linux || freebsd || openbsd || netbsd || dragonfly
+
+// Package helpers - freedesktop.org Trash
+package helpers
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// TrashSupported - -tr works on this platform
+const TrashSupported = true
+
+// trashFile - moves path to the Trash of its filesystem: the home Trash when it is on the same
+// filesystem as the home directory, otherwise .Trash/$UID or .Trash-$UID at the mount point.
+// returns where the file is now
+func trashFile(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	home, err := homeTrash()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(home, 0700); err != nil {
+		return "", err
+	}
+
+	dev, err := deviceOf(path)
+	if err != nil {
+		return "", err
+	}
+	homeDev, err := deviceOf(home)
+	if err != nil {
+		return "", err
+	}
+	if dev == homeDev {
+		return moveToTrash(path, home, path)
+	}
+
+	top := mountRoot(path, dev)
+	trashDir, err := topdirTrash(top)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(top, path)
+	if err != nil {
+		return "", err
+	}
+	return moveToTrash(path, trashDir, rel)
+}
+
+// homeTrash - $XDG_DATA_HOME/Trash, ~/.local/share/Trash by default
+func homeTrash() (string, error) {
+	if dataHome := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dataHome) {
+		return filepath.Join(dataHome, "Trash"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "Trash"), nil
+}
+
+// topdirTrash - the shared .Trash/$UID when the admin set up a sticky .Trash, otherwise .Trash-$UID
+func topdirTrash(top string) (string, error) {
+	uid := strconv.Itoa(os.Getuid())
+	if info, err := os.Lstat(filepath.Join(top, ".Trash")); err == nil && info.IsDir() && info.Mode()&os.ModeSticky != 0 {
+		dir := filepath.Join(top, ".Trash", uid)
+		if err := os.MkdirAll(dir, 0700); err == nil && isRealDir(dir) {
+			return dir, nil
+		}
+	}
+
+	dir := filepath.Join(top, ".Trash-"+uid)
+	if err := os.Mkdir(dir, 0700); err != nil && !os.IsExist(err) {
+		return "", err
+	}
+	if !isRealDir(dir) {
+		return "", fmt.Errorf("%s is not a directory", dir)
+	}
+	return dir, nil
+}
+
+// moveToTrash - writes the .trashinfo first, so a file in the Trash always has one. infoPath is
+// the Path= entry: absolute in the home Trash, relative to the mount point in the others
+func moveToTrash(path, trashDir, infoPath string) (string, error) {
+	for _, dir := range []string{"files", "info"} {
+		if err := os.MkdirAll(filepath.Join(trashDir, dir), 0700); err != nil {
+			return "", err
+		}
+	}
+
+	base := filepath.Base(path)
+	ext := filepath.Ext(base)
+	name := strings.TrimSuffix(base, ext)
+	for i := 1; ; i++ {
+		trashName := base
+		if i > 1 {
+			trashName = fmt.Sprintf("%s.%d%s", name, i, ext)
+		}
+		dst := filepath.Join(trashDir, "files", trashName)
+		if FileExists(dst) {
+			continue
+		}
+		infoFile := filepath.Join(trashDir, "info", trashName+".trashinfo")
+		f, err := os.OpenFile(infoFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+
+		info := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+			(&url.URL{Path: infoPath}).EscapedPath(), time.Now().Format("2006-01-02T15:04:05"))
+		_, err = f.WriteString(info)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Rename(path, dst)
+		}
+		if err != nil {
+			_ = os.Remove(infoFile)
+			return "", err
+		}
+		return dst, nil
+	}
+}
+
+// mountRoot - topmost directory above path that is still on the filesystem dev
+func mountRoot(path string, dev uint64) string {
+	dir := filepath.Dir(path)
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		if parentDev, err := deviceOf(parent); err != nil || parentDev != dev {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+func deviceOf(path string) (uint64, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return 0, err
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, fmt.Errorf("no device for %s", path)
+	}
+	return uint64(st.Dev), nil // the type of Dev differs between platforms
+}
+
+// isRealDir - a directory and not a symlink to one
+func isRealDir(path string) bool {
+	info, err := os.Lstat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,128 @@
+// Package helpers - name variants (browser/OS copies)
+package helpers
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/mohamedation/GoSorter/model"
+)
+
+// VariantKind - how sure a name is to be a copy of another file
+type VariantKind int
+
+const (
+	NotVariant      VariantKind = iota
+	WeakVariant                 // "name copy", also an ordinary name like "Hard copy": only identical files are copies
+	NumberedVariant             // "name (1)", a copy when the original is there too
+	ExplicitVariant             // "name - Copy", "name copy 2", always a copy
+)
+
+type variantPattern struct {
+	re   *regexp.Regexp
+	kind VariantKind
+}
+
+// order matters, more specific patterns first
+var variantPatterns = []variantPattern{
+	{regexp.MustCompile(`(?i)^(.+?) - copy(?: \(\d+\))?$`), ExplicitVariant}, // windows: "name - Copy", "name - Copy (2)"
+	{regexp.MustCompile(`(?i)^(.+?) copy \d+$`), ExplicitVariant},            // macOS: "name copy 2"
+	{regexp.MustCompile(`(?i)^(.+?) copy$`), WeakVariant},                    // macOS: "name copy"
+	// browsers and GoSorter: "name (1)", "name(2)". four digits are a year: "Tax return (2023)"
+	{regexp.MustCompile(`^(.+?) ?\(\d{1,3}\)$`), NumberedVariant},
+}
+
+// renamePatternTokens - regexp of each conflict_rename_pattern token
+var renamePatternTokens = map[string]string{
+	"name": `(?P<name>.+?)`,
+	"n":    `\d{1,3}`,
+	"ext":  `(?P<ext>\.[^.]*)?`,
+}
+
+// RenamedCopyPattern - matches the names conflict_rename_pattern gives to conflicting files,
+// nil for the default pattern, which is a "name(1)" variant already
+func RenamedCopyPattern(pattern string) *regexp.Regexp {
+	if pattern == "" || pattern == model.DefaultConflictRenamePattern {
+		return nil
+	}
+	var b strings.Builder
+	b.WriteString("^")
+	rest := pattern
+	for {
+		start := strings.IndexByte(rest, '{')
+		end := strings.IndexByte(rest, '}')
+		if start < 0 || end < start {
+			break
+		}
+		b.WriteString(regexp.QuoteMeta(rest[:start]))
+		if expr, ok := renamePatternTokens[rest[start+1:end]]; ok {
+			b.WriteString(expr)
+		} else {
+			b.WriteString(regexp.QuoteMeta(rest[start : end+1]))
+		}
+		rest = rest[end+1:]
+	}
+	b.WriteString(regexp.QuoteMeta(rest))
+	b.WriteString("$")
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil
+	}
+	return re
+}
+
+// VariantBaseName - returns the original file name a copy was made from,
+// e.g. "report (1).pdf" -> "report.pdf". ok is false if the name is not a copy.
+// copies of copies ("report (1) copy.pdf") are unwrapped all the way down.
+func VariantBaseName(fileName string) (string, bool) {
+	base, kind := ParseVariant(fileName, nil)
+	return base, kind != NotVariant
+}
+
+// ParseVariant - original file name and how sure the name is a copy. a copy of a copy is
+// explicit when any suffix is, otherwise weak when any suffix is. renamed (RenamedCopyPattern)
+// also recognizes the names of a custom conflict_rename_pattern as numbered copies
+func ParseVariant(fileName string, renamed *regexp.Regexp) (string, VariantKind) {
+	kind := NotVariant
+	if renamed != nil {
+		if m := renamed.FindStringSubmatch(fileName); m != nil && strings.TrimSpace(m[renamed.SubexpIndex("name")]) != "" {
+			fileName = m[renamed.SubexpIndex("name")]
+			if i := renamed.SubexpIndex("ext"); i >= 0 {
+				fileName += m[i]
+			}
+			kind = NumberedVariant
+		}
+	}
+	ext := filepath.Ext(fileName)
+	stem := strings.TrimSuffix(fileName, ext)
+	for {
+		base, k := stripVariantSuffix(stem)
+		if k == NotVariant {
+			break
+		}
+		stem = base
+		switch {
+		case kind == NotVariant, k == ExplicitVariant:
+			kind = k
+		case k == WeakVariant && kind != ExplicitVariant:
+			kind = WeakVariant
+		}
+	}
+	return stem + ext, kind
+}
+
+func stripVariantSuffix(stem string) (string, VariantKind) {
+	for _, p := range variantPatterns {
+		m := p.re.FindStringSubmatch(stem)
+		if m == nil {
+			continue
+		}
+		base := strings.TrimSpace(m[1])
+		if base == "" {
+			return stem, NotVariant
+		}
+		return base, p.kind
+	}
+	return stem, NotVariant
+}
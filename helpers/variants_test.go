@@ -0,0 +1,60 @@
+// Package helpers - name variant tests
+package helpers
+
+import "testing"
+
+func TestVariantBaseName(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   string
+		wantOK bool
+	}{
+		{"report (1).pdf", "report.pdf", true},
+		{"report(2).pdf", "report.pdf", true},
+		{"report - Copy.pdf", "report.pdf", true},
+		{"report - Copy (3).pdf", "report.pdf", true},
+		{"report copy.pdf", "report.pdf", true},
+		{"report copy 2.pdf", "report.pdf", true},
+		{"report (1) copy.pdf", "report.pdf", true},
+		{"report.pdf", "report.pdf", false},
+		{"(1).pdf", "(1).pdf", false},
+		{"photocopy.pdf", "photocopy.pdf", false},
+		{"Tax return (2023).pdf", "Tax return (2023).pdf", false},
+		{"Tax return (2023) (1).pdf", "Tax return (2023).pdf", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := VariantBaseName(tt.name)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("VariantBaseName(%q) = %q, %v; want %q, %v", tt.name, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseVariant_RenamePattern(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		name     string
+		want     string
+		wantKind VariantKind
+	}{
+		{"{name}_{n}{ext}", "report_1.pdf", "report.pdf", NumberedVariant},
+		{"{name} [{n}]{ext}", "report [12].pdf", "report.pdf", NumberedVariant},
+		{"{name} [{n}]{ext}", "report [12] - Copy.pdf", "report [12].pdf", ExplicitVariant},
+		{"{name}-{n}{ext}", "archive.tar-2.gz", "archive.tar.gz", NumberedVariant},
+		{"{name}_{n}{ext}", "IMG_2023.jpg", "IMG_2023.jpg", NotVariant}, // four digits are not a counter
+		{"{name}_{n}{ext}", "_1.pdf", "_1.pdf", NotVariant},
+		{"{name}({n}){ext}", "report(1).pdf", "report.pdf", NumberedVariant}, // default, nil matcher
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.name, func(t *testing.T) {
+			got, kind := ParseVariant(tt.name, RenamedCopyPattern(tt.pattern))
+			if got != tt.want || kind != tt.wantKind {
+				t.Errorf("ParseVariant(%q) = %q, %v; want %q, %v", tt.name, got, kind, tt.want, tt.wantKind)
+			}
+		})
+	}
+}
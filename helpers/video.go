@@ -0,0 +1,309 @@
+// Package helpers - video metadata
+package helpers
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// VideoInfo - container level metadata, no decoding involved
+type VideoInfo struct {
+	Duration time.Duration
+	Width    int
+	Height   int
+	Created  time.Time
+}
+
+var errNoVideoInfo = errors.New("no video metadata found")
+
+var (
+	mp4Epoch      = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	matroskaEpoch = time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+const maxEBMLMasterSize = 4 << 20 // Info and Tracks elements, usually a few hundred bytes
+
+// ReadVideoInfo - duration, resolution and recording time from MP4/MOV or Matroska/WebM
+func ReadVideoInfo(filePath string) (*VideoInfo, error) {
+	filePath = filepath.Clean(filePath)
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return nil, errNoVideoInfo
+	}
+
+	switch {
+	case binary.BigEndian.Uint32(header) == 0x1A45DFA3:
+		return readMatroskaInfo(file, info.Size())
+	case string(header[4:8]) == "ftyp" || string(header[4:8]) == "moov" ||
+		string(header[4:8]) == "wide" || string(header[4:8]) == "mdat":
+		return readMP4Info(file, info.Size())
+	}
+	return nil, errNoVideoInfo
+}
+
+func readMP4Info(r io.ReaderAt, size int64) (*VideoInfo, error) {
+	moov, err := findBox(r, 0, size, "moov")
+	if err != nil {
+		return nil, errNoVideoInfo
+	}
+
+	vi := &VideoInfo{}
+	found := false
+	eachBox(r, moov.offset, moov.offset+moov.size, func(boxType string, b isoBox) bool {
+		switch boxType {
+		case "mvhd":
+			if parseMvhd(r, b, vi) {
+				found = true
+			}
+		case "trak":
+			if vi.Width == 0 {
+				if tkhd, err := findBox(r, b.offset, b.offset+b.size, "tkhd"); err == nil {
+					parseTkhd(r, tkhd, vi)
+				}
+			}
+		}
+		return true
+	})
+	if !found {
+		return nil, errNoVideoInfo
+	}
+	return vi, nil
+}
+
+func parseMvhd(r io.ReaderAt, b isoBox, vi *VideoInfo) bool {
+	buf := make([]byte, 32)
+	if b.size < 20 {
+		return false
+	}
+	n := int64(len(buf))
+	if b.size < n {
+		n = b.size
+	}
+	if _, err := r.ReadAt(buf[:n], b.offset); err != nil {
+		return false
+	}
+	var created, timescale, duration uint64
+	if buf[0] == 1 {
+		if n < 32 {
+			return false
+		}
+		created = binary.BigEndian.Uint64(buf[4:12])
+		timescale = uint64(binary.BigEndian.Uint32(buf[20:24]))
+		duration = binary.BigEndian.Uint64(buf[24:32])
+	} else {
+		created = uint64(binary.BigEndian.Uint32(buf[4:8]))
+		timescale = uint64(binary.BigEndian.Uint32(buf[12:16]))
+		duration = uint64(binary.BigEndian.Uint32(buf[16:20]))
+	}
+	if created != 0 {
+		vi.Created = mp4Epoch.Add(time.Duration(created) * time.Second)
+	}
+	if timescale != 0 {
+		vi.Duration = time.Duration(float64(duration) / float64(timescale) * float64(time.Second))
+	}
+	return true
+}
+
+// width and height are 16.16 fixed point at the end of tkhd, zero for audio tracks
+func parseTkhd(r io.ReaderAt, b isoBox, vi *VideoInfo) {
+	if b.size < 84 {
+		return
+	}
+	buf := make([]byte, 8)
+	if _, err := r.ReadAt(buf, b.offset+b.size-8); err != nil {
+		return
+	}
+	width := int(binary.BigEndian.Uint32(buf[:4]) >> 16)
+	height := int(binary.BigEndian.Uint32(buf[4:]) >> 16)
+	if width > 0 && height > 0 {
+		vi.Width, vi.Height = width, height
+	}
+}
+
+// Matroska element IDs
+const (
+	ebmlSegment       = 0x18538067
+	ebmlInfo          = 0x1549A966
+	ebmlTracks        = 0x1654AE6B
+	ebmlCluster       = 0x1F43B675
+	ebmlTimecodeScale = 0x2AD7B1
+	ebmlDuration      = 0x4489
+	ebmlDateUTC       = 0x4461
+	ebmlTrackEntry    = 0xAE
+	ebmlVideo         = 0xE0
+	ebmlPixelWidth    = 0xB0
+	ebmlPixelHeight   = 0xBA
+)
+
+// readVint - EBML variable size integer, keepMarker for element IDs
+func readVint(buf []byte, keepMarker bool) (uint64, int, bool) {
+	if len(buf) == 0 || buf[0] == 0 {
+		return 0, 0, false
+	}
+	length := 1
+	for mask := byte(0x80); buf[0]&mask == 0; mask >>= 1 {
+		length++
+	}
+	if length > 8 || length > len(buf) {
+		return 0, 0, false
+	}
+	v := uint64(buf[0])
+	if !keepMarker {
+		v &= uint64(0xFF >> length)
+	}
+	for _, b := range buf[1:length] {
+		v = v<<8 | uint64(b)
+	}
+	return v, length, true
+}
+
+// readElementHeader - id, payload size and header length of the element at offset
+func readElementHeader(r io.ReaderAt, offset int64) (uint64, int64, int64, bool) {
+	buf := make([]byte, 12)
+	n, _ := r.ReadAt(buf, offset)
+	buf = buf[:n]
+	id, idLen, ok := readVint(buf, true)
+	if !ok {
+		return 0, 0, 0, false
+	}
+	size, sizeLen, ok := readVint(buf[idLen:], false)
+	if !ok {
+		return 0, 0, 0, false
+	}
+	// all ones means unknown size (live streams)
+	if size == uint64(1)<<(7*sizeLen)-1 {
+		size = math.MaxInt64
+	}
+	return id, int64(size), int64(idLen + sizeLen), true
+}
+
+func readMatroskaInfo(r io.ReaderAt, size int64) (*VideoInfo, error) {
+	// skip the EBML header
+	_, headerSize, headerLen, ok := readElementHeader(r, 0)
+	if !ok {
+		return nil, errNoVideoInfo
+	}
+	offset := headerLen + headerSize
+	id, segSize, segHeaderLen, ok := readElementHeader(r, offset)
+	if !ok || id != ebmlSegment {
+		return nil, errNoVideoInfo
+	}
+	offset += segHeaderLen
+	end := size
+	if segSize < size-offset {
+		end = offset + segSize
+	}
+
+	vi := &VideoInfo{}
+	found := false
+	timecodeScale := uint64(1000000)
+	var duration float64
+	for offset < end {
+		id, elSize, elHeaderLen, ok := readElementHeader(r, offset)
+		if !ok || elSize == math.MaxInt64 {
+			break
+		}
+		// metadata comes before the clusters
+		if id == ebmlCluster {
+			break
+		}
+		if (id == ebmlInfo || id == ebmlTracks) && elSize <= maxEBMLMasterSize {
+			buf := make([]byte, elSize)
+			if _, err := r.ReadAt(buf, offset+elHeaderLen); err == nil {
+				found = true
+				if id == ebmlInfo {
+					walkEBML(buf, func(id uint64, payload []byte) bool {
+						switch id {
+						case ebmlTimecodeScale:
+							timecodeScale = ebmlUint(payload)
+						case ebmlDuration:
+							duration = ebmlFloat(payload)
+						case ebmlDateUTC:
+							if len(payload) == 8 {
+								ns := int64(binary.BigEndian.Uint64(payload))
+								vi.Created = matroskaEpoch.Add(time.Duration(ns))
+							}
+						}
+						return false
+					})
+				} else {
+					walkEBML(buf, func(id uint64, payload []byte) bool {
+						switch id {
+						case ebmlTrackEntry, ebmlVideo:
+							return true // descend
+						case ebmlPixelWidth:
+							if vi.Width == 0 {
+								vi.Width = int(ebmlUint(payload))
+							}
+						case ebmlPixelHeight:
+							if vi.Height == 0 {
+								vi.Height = int(ebmlUint(payload))
+							}
+						}
+						return false
+					})
+				}
+			}
+		}
+		offset += elHeaderLen + elSize
+	}
+	if !found {
+		return nil, errNoVideoInfo
+	}
+	vi.Duration = time.Duration(duration * float64(timecodeScale))
+	return vi, nil
+}
+
+// walkEBML - visits elements in buf, fn returns true to descend into a master element
+func walkEBML(buf []byte, fn func(id uint64, payload []byte) bool) {
+	for len(buf) > 0 {
+		id, idLen, ok := readVint(buf, true)
+		if !ok {
+			return
+		}
+		size, sizeLen, ok := readVint(buf[idLen:], false)
+		if !ok || uint64(len(buf)-idLen-sizeLen) < size {
+			return
+		}
+		start := idLen + sizeLen
+		payload := buf[start : start+int(size)]
+		if fn(id, payload) {
+			walkEBML(payload, fn)
+		}
+		buf = buf[start+int(size):]
+	}
+}
+
+func ebmlUint(b []byte) uint64 {
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v
+}
+
+func ebmlFloat(b []byte) float64 {
+	switch len(b) {
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b)))
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
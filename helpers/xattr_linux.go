@@ -0,0 +1,51 @@
+//go:build linux
+
+// Package helpers - extended attributes (linux)
+package helpers
+
+import (
+	"errors"
+	"strings"
+	"syscall"
+)
+
+// copyXattrs - copies the extended attributes of src to dst (user tags, capabilities, ACLs).
+// attributes the destination filesystem refuses are skipped, the first failure is returned
+func copyXattrs(src, dst string) error {
+	size, err := syscall.Listxattr(src, nil)
+	if err != nil || size == 0 {
+		if errors.Is(err, syscall.ENOTSUP) {
+			return nil
+		}
+		return err
+	}
+	list := make([]byte, size)
+	size, err = syscall.Listxattr(src, list)
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+	for _, name := range strings.Split(string(list[:size]), "\x00") {
+		if name == "" {
+			continue
+		}
+		if err := copyXattr(src, dst, name); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func copyXattr(src, dst, name string) error {
+	size, err := syscall.Getxattr(src, name, nil)
+	if err != nil {
+		return err
+	}
+	value := make([]byte, size)
+	size, err = syscall.Getxattr(src, name, value)
+	if err != nil {
+		return err
+	}
+	return syscall.Setxattr(dst, name, value[:size], 0)
+}
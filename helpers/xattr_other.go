@@ -0,0 +1,9 @@
+//go:build !linux
+
+// Package helpers - extended attributes (other platforms)
+package helpers
+
+// copyXattrs - extended attributes are not copied outside linux
+func copyXattrs(_, _ string) error {
+	return nil
+}
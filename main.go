@@ -2,10 +2,14 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"runtime"
+	"syscall"
 	"time"
 
 	"github.com/mohamedation/GoSorter/helpers"
@@ -31,7 +35,18 @@ func main() {
 	flag.BoolVar(&cfg.DuplicatesOnly, "do", false, "Only detect and move duplicates, no sorting")
 	flag.BoolVar(&cfg.Verbose, "v", false, "Enable verbose output")
 	flag.BoolVar(&cfg.Silent, "s", false, "silent output")
-	flag.BoolVar(&cfg.DetectTransparentPNGs, "t", false, "Check transparent PNGs (slower, but sorts PNGs with transparent backgrounds into PNGs folder)")
+	flag.BoolVar(&cfg.DetectTransparentPNGs, "t", false, "Check transparent PNG, GIF and WebP images (slower, but sorts images with transparent backgrounds into PNGs folder)")
+	flag.Float64Var(&cfg.TransparencyThreshold, "T", model.DefaultTransparencyThreshold, "Share of transparent pixels for -t to count an image as transparent (0-1)")
+	flag.BoolVar(&cfg.DetectScreenshots, "ss", false, "Move screenshots and screen recordings to their own folders")
+	flag.IntVar(&cfg.ClassifyWorkers, "w", model.DefaultWorkerCount, "Files inspected in parallel (metadata, transparency, screenshots)")
+	flag.IntVar(&cfg.MoveWorkers, "mw", 1, "Parallel moves, moves into the same folder stay in order")
+	flag.StringVar(&cfg.ConflictPolicy, "C", "", "What to do when a file with the same name exists: rename, skip, overwrite_if_newer, keep_larger, duplicates or prompt")
+	flag.DurationVar(&cfg.QuietPeriod, "q", 0, "Skip files modified within this time, e.g. 10m (partial downloads and files open for writing are always skipped)")
+	flag.BoolVar(&cfg.UseTrash, "tr", false, "Move files that are replaced at the destination to the Trash instead of deleting them")
+	flag.BoolVar(&cfg.VerifyCopies, "vc", false, "Verify files copied to another filesystem by hash before removing the original")
+	flag.BoolVar(&cfg.Force, "f", false, "Sort the directory even if it is /, the home directory, a system directory, the root of a drive or a project")
+	resume := flag.Bool("resume", false, "Continue the interrupted duplicate detection run of the directory, or the last one")
+	flag.BoolVar(&cfg.ConsolidateCopies, "c", false, "Consolidate copies like \"file (1).pdf\" and \"file copy.pdf\" (duplicates to Duplicates, older versions to Versions)")
 
 	// max hash file size (2048M or 2G)
 	var maxHashSizeStr string
@@ -63,6 +78,18 @@ func main() {
 		logger.Log(cfg, helpers.Error, fmt.Sprintf("Configuration error: %v\n", err))
 		os.Exit(1)
 	}
+	// a broken extension.json would otherwise sort with defaults the user never chose
+	if _, err := model.ReadExtensionConfig(); err != nil {
+		logger := &helpers.CLILogger{}
+		logger.Log(cfg, helpers.Error, fmt.Sprintf("Configuration error: invalid extension configuration:\n  %v\n", err))
+		os.Exit(1)
+	}
+	// otherwise every replaced or deleted file would fail on its own halfway through the run
+	if cfg.UseTrash && !helpers.TrashSupported {
+		logger := &helpers.CLILogger{}
+		logger.Log(cfg, helpers.Error, fmt.Sprintf("Configuration error: -tr is not supported on %s, only on Linux and the BSDs\n", runtime.GOOS))
+		os.Exit(1)
+	}
 
 	// max hash size
 	cfg.MaxHashFileSizeMB = 1024 // default 1GB
@@ -96,19 +123,74 @@ func main() {
 		folderPath = flag.Arg(0)
 	}
 
+	if *resume {
+		folderPath = resumeRun(&cfg, flag.Args())
+	} else if cfg.MoveDuplicates {
+		// long hashing runs can be picked up again with "gosorter -resume"
+		if path, err := model.CheckpointPath(folderPath); err == nil {
+			cfg.CheckpointFile = path
+		}
+	}
+
 	processor := service.NewFileProcessor(&cfg, stats, &helpers.CLILogger{})
 
-	ctx := context.Background()
-	if err := processor.ProcessDirectory(ctx, folderPath); err != nil {
+	// Ctrl-C / SIGTERM stop the run after the files in flight, a second signal kills it right away
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	err := processor.ProcessDirectory(ctx, folderPath)
+	stop()
+	if errors.Is(err, context.Canceled) {
+		logger := &helpers.CLILogger{}
+		logger.Log(cfg, helpers.Error, fmt.Sprintf("Interrupted, %d files were moved before stopping\n", stats.GetFilesMoved()+stats.GetDuplicatesMoved()))
+		if cfg.CheckpointFile != "" {
+			logger.Log(cfg, helpers.Normal, fmt.Sprintf("Continue with: %s -resume %s\n", filepath.Base(os.Args[0]), folderPath))
+		}
+		printStats(cfg, stats, true)
+		os.Exit(130)
+	}
+	if err != nil {
 		fmt.Printf("Error processing directory: %v\n", err)
 		os.Exit(1)
 	}
 
 	// statistics
-	printStats(cfg, stats)
+	printStats(cfg, stats, false)
 }
 
-func printStats(cfg model.Config, stats *model.Stats) {
+// resumeRun - restores the options of an interrupted run from its checkpoint, returns its directory.
+// without a directory the most recently interrupted run is resumed
+func resumeRun(cfg *model.Config, args []string) string {
+	var path string
+	var err error
+	if len(args) > 0 {
+		path, err = model.CheckpointPath(args[0])
+	} else {
+		path, err = model.LatestCheckpoint()
+	}
+	var cp *model.Checkpoint
+	if err == nil {
+		cp, err = model.LoadCheckpoint(path)
+	}
+	if err != nil {
+		logger := &helpers.CLILogger{}
+		logger.Log(*cfg, helpers.Error, fmt.Sprintf("Nothing to resume: %v\n", err))
+		os.Exit(1)
+	}
+
+	// output options come from this invocation, everything else from the interrupted run
+	resumed := cp.Config
+	resumed.Verbose, resumed.Silent, resumed.LogFilePath = cfg.Verbose, cfg.Silent, cfg.LogFilePath
+	resumed.CheckpointFile = path
+	resumed.Resume = true
+	*cfg = resumed
+	return cp.Folder
+}
+
+func printStats(cfg model.Config, stats *model.Stats, interrupted bool) {
 	// no stats
 	if !cfg.Verbose && cfg.LogFilePath == "" {
 		return
@@ -120,17 +202,42 @@ func printStats(cfg model.Config, stats *model.Stats) {
 	// needs some work
 	statsContent := "\n====================[ GoSorter Stats ]====================\n"
 	statsContent += fmt.Sprintf("%-25s %s\n", "Started at:", stats.StartTime.Format(time.RFC1123))
-	statsContent += fmt.Sprintf("%-25s %s\n", "Finished at:", stats.EndTime.Format(time.RFC1123))
+	if interrupted {
+		statsContent += fmt.Sprintf("%-25s %s\n", "Interrupted at:", stats.EndTime.Format(time.RFC1123))
+	} else {
+		statsContent += fmt.Sprintf("%-25s %s\n", "Finished at:", stats.EndTime.Format(time.RFC1123))
+	}
 	statsContent += fmt.Sprintf("%-25s %s\n", "Duration:", stats.TimeElapsed)
 	statsContent += "------------------------------------------------------------\n"
 	statsContent += fmt.Sprintf("%-25s %d\n", "Total files processed:", stats.GetTotalFiles())
 	statsContent += fmt.Sprintf("%-25s %d\n", "Files moved:", stats.GetFilesMoved())
-	if cfg.MoveDuplicates {
+	if cfg.MoveDuplicates || cfg.ConsolidateCopies {
 		statsContent += fmt.Sprintf("%-25s %d\n", "Duplicate files moved:", stats.GetDuplicatesMoved())
 	}
 	if cfg.DetectTransparentPNGs {
 		statsContent += fmt.Sprintf("%-25s %d\n", "Transparent PNGs moved:", stats.GetTransparentPNGsMoved())
 	}
+	if cfg.DetectScreenshots {
+		statsContent += fmt.Sprintf("%-25s %d\n", "Screen captures moved:", stats.GetScreenshotsMoved())
+	}
+	if cfg.ConsolidateCopies {
+		statsContent += fmt.Sprintf("%-25s %d\n", "Older versions moved:", stats.GetVersionsMoved())
+	}
+	if stats.GetSkipped() > 0 {
+		statsContent += fmt.Sprintf("%-25s %d\n", "Files skipped:", stats.GetSkipped())
+	}
+	if stats.GetJunkDeleted() > 0 {
+		statsContent += fmt.Sprintf("%-25s %d\n", "Junk files deleted:", stats.GetJunkDeleted())
+	}
+	if stats.GetIgnored() > 0 {
+		statsContent += fmt.Sprintf("%-25s %d\n", "Ignored files:", stats.GetIgnored())
+	}
+	if stats.GetInProgressSkipped() > 0 {
+		statsContent += fmt.Sprintf("%-25s %d\n", "Still being written:", stats.GetInProgressSkipped())
+	}
+	if stats.GetConflictsSkipped() > 0 {
+		statsContent += fmt.Sprintf("%-25s %d\n", "Conflicts skipped:", stats.GetConflictsSkipped())
+	}
 	if stats.GetUnknownExtensions() > 0 {
 		statsContent += fmt.Sprintf("%-25s %d\n", "Unknown extensions:", stats.GetUnknownExtensions())
 	}
@@ -187,7 +294,18 @@ func printHelp() {
 		{"-v", "Enable verbose output with detailed statistics"},
 		{"-s", "Enable silent output"},
 		{"-l", "Enable logging to a file in the current directory"},
-		{"-t", "Check transparent PNGs (slower, but sorts PNGs with transparent backgrounds)"},
+		{"-t", "Check transparent PNG, GIF and WebP images (slower, but sorts images with transparent backgrounds)"},
+		{"-T", "Share of transparent pixels for -t, e.g. -T 0.1 (default 0.5)"},
+		{"-ss", "Detect screenshots (file name, screen resolution, no camera EXIF) and screen recordings"},
+		{"-w", "Files inspected in parallel, e.g. -w 16 (default 8)"},
+		{"-mw", "Parallel moves, e.g. -mw 4 for network shares (default 1)"},
+		{"-C", "Name conflicts: rename (default), skip, overwrite_if_newer, keep_larger, duplicates, prompt"},
+		{"-q", "Skip files modified within this time, e.g. -q 10m for cron jobs on Downloads"},
+		{"-tr", "Move replaced files to the Trash instead of deleting them (Linux, BSD)"},
+		{"-vc", "Verify files copied to another drive by hash before removing the original"},
+		{"-c", "Consolidate copies like \"file (1).pdf\", \"file - Copy.pdf\", \"file copy 2.pdf\""},
+		{"-resume", "Continue an interrupted -d or -do run with its options, e.g. -resume ~/Downloads"},
+		{"-f", "Sort home, system and project directories and drive roots, which are refused by default"},
 	}
 	for _, opt := range options {
 		logger.Log(cfg, helpers.Normal, fmt.Sprintf("  %-4s %s\n", opt.flag, opt.desc))
@@ -199,6 +317,10 @@ func printHelp() {
 		{progName + " -d -v ~/Documents", "Sort with duplicate detection and verbose output"},
 		{progName + " -do ~/Documents", "Only detect and move duplicates, skip sorting"},
 		{progName + " -t ~/Pictures", "Sort with transparent PNG detection"},
+		{progName + " -ss ~/Pictures", "Separate screenshots from photos"},
+		{progName + " -c ~/Downloads", "Clean up browser download copies, then sort"},
+		{progName + " -C prompt ~/Downloads", "Ask before renaming or replacing existing files"},
+		{progName + " -resume", "Continue the last interrupted duplicate detection run"},
 	}
 	for _, ex := range examples {
 		logger.Log(cfg, helpers.Normal, fmt.Sprintf("  %-30s # %s\n", ex.cmd, ex.desc))
@@ -213,7 +335,10 @@ func printHelp() {
     },
     "archives_extracted_folder": "Archives-Extracted",
     "duplicates_folder": "Duplicates",
-    "transparent_png_folder": "PNGs"
+    "transparent_png_folder": "PNGs",
+    "versions_folder": "Versions",
+    "screenshots_folder": "Screenshots",
+    "photo_layout": "date"
   }`
 	logger.Log(cfg, helpers.Normal, configExample+"\n")
 }
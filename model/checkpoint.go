@@ -0,0 +1,158 @@
+// Package model - checkpoints
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrNoCheckpoint - there is no interrupted run to resume
+var ErrNoCheckpoint = errors.New("no checkpoint found")
+
+// Checkpoint - progress of a duplicate detection run, saved while it runs so an
+// interrupted or crashed run can be resumed without hashing everything again
+type Checkpoint struct {
+	Folder    string                `json:"folder"` // absolute path of the sorted directory
+	Config    Config                `json:"config"`
+	Started   time.Time             `json:"started"`
+	Hashes    map[string]FileHashes `json:"hashes"`    // file name -> hashes computed so far
+	Originals map[string]string     `json:"originals"` // content hash -> path of the file kept for it
+}
+
+// FileHashes - hashes of a file, only valid while its size and modification time are unchanged
+type FileHashes struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	Partial string    `json:"partial,omitempty"`
+	Full    string    `json:"full,omitempty"`
+}
+
+func NewCheckpoint(folder string, cfg Config) *Checkpoint {
+	return &Checkpoint{
+		Folder:    folder,
+		Config:    cfg,
+		Started:   time.Now(),
+		Hashes:    make(map[string]FileHashes),
+		Originals: make(map[string]string),
+	}
+}
+
+// checkpoints ~/.config/GoSorter/checkpoints, one file per directory
+func checkpointDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".config", "GoSorter", "checkpoints"), nil
+}
+
+// CheckpointPath - checkpoint file of a directory
+func CheckpointPath(folder string) (string, error) {
+	abs, err := filepath.Abs(folder)
+	if err != nil {
+		return "", err
+	}
+	dir, err := checkpointDir()
+	if err != nil {
+		return "", err
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(abs))
+	return filepath.Join(dir, fmt.Sprintf("%016x.json", h.Sum64())), nil
+}
+
+// LatestCheckpoint - checkpoint file of the most recently interrupted run
+func LatestCheckpoint() (string, error) {
+	dir, err := checkpointDir()
+	if err != nil {
+		return "", err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", ErrNoCheckpoint
+		}
+		return "", err
+	}
+	var latest string
+	var latestTime time.Time
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if latest == "" || info.ModTime().After(latestTime) {
+			latest = filepath.Join(dir, entry.Name())
+			latestTime = info.ModTime()
+		}
+	}
+	if latest == "" {
+		return "", ErrNoCheckpoint
+	}
+	return latest, nil
+}
+
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNoCheckpoint
+		}
+		return nil, err
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("corrupt checkpoint %s: %w", path, err)
+	}
+	if cp.Hashes == nil {
+		cp.Hashes = make(map[string]FileHashes)
+	}
+	if cp.Originals == nil {
+		cp.Originals = make(map[string]string)
+	}
+	return &cp, nil
+}
+
+// Save - written to a temporary file and renamed over the old checkpoint,
+// so a crash while saving leaves the previous one intact
+func (cp *Checkpoint) Save(path string) error {
+	path = filepath.Clean(path)
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return err
+	}
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".checkpoint-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Valid - hashes still describe a file of this size and modification time
+func (h FileHashes) Valid(size int64, modTime time.Time) bool {
+	return h.Size == size && h.ModTime.Equal(modTime)
+}
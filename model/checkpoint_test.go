@@ -0,0 +1,86 @@
+// Package model - checkpoint tests
+package model
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCheckpoint_SaveLoadLatest(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_checkpoint_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	originalHome := os.Getenv("HOME")
+	if err := os.Setenv("HOME", tempDir); err != nil {
+		t.Fatalf("Failed to set HOME: %v", err)
+	}
+	defer func() {
+		if err := os.Setenv("HOME", originalHome); err != nil {
+			t.Fatalf("Failed to restore HOME: %v", err)
+		}
+	}()
+
+	if _, err := LatestCheckpoint(); err != ErrNoCheckpoint {
+		t.Errorf("Expected ErrNoCheckpoint without checkpoints, got %v", err)
+	}
+
+	first, err := CheckpointPath("/data/photos")
+	if err != nil {
+		t.Fatalf("CheckpointPath failed: %v", err)
+	}
+	second, err := CheckpointPath("/data/music")
+	if err != nil {
+		t.Fatalf("CheckpointPath failed: %v", err)
+	}
+	if first == second {
+		t.Fatal("Expected different checkpoint files for different directories")
+	}
+
+	modTime := time.Date(2024, 5, 1, 10, 30, 0, 123456789, time.UTC)
+	cp := NewCheckpoint("/data/photos", Config{MoveDuplicates: true, MaxHashFileSizeMB: 2048})
+	cp.Hashes["a.jpg"] = FileHashes{Size: 42, ModTime: modTime, Partial: "p", Full: "f"}
+	cp.Originals["f"] = "/data/photos/a.jpg"
+	if err := cp.Save(first); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := NewCheckpoint("/data/music", Config{MoveDuplicates: true}).Save(second); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	older := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(first, older, older); err != nil {
+		t.Fatalf("Failed to age checkpoint: %v", err)
+	}
+
+	loaded, err := LoadCheckpoint(first)
+	if err != nil {
+		t.Fatalf("LoadCheckpoint failed: %v", err)
+	}
+	if loaded.Folder != "/data/photos" || loaded.Config.MaxHashFileSizeMB != 2048 {
+		t.Errorf("Expected folder and config to survive, got %s and %d", loaded.Folder, loaded.Config.MaxHashFileSizeMB)
+	}
+	if h := loaded.Hashes["a.jpg"]; !h.Valid(42, modTime) || h.Full != "f" {
+		t.Errorf("Expected saved hashes to stay valid, got %+v", h)
+	}
+	if h := loaded.Hashes["a.jpg"]; h.Valid(42, modTime.Add(time.Second)) {
+		t.Error("Expected hashes of a modified file to be invalid")
+	}
+	if loaded.Originals["f"] != "/data/photos/a.jpg" {
+		t.Errorf("Expected original to survive, got %q", loaded.Originals["f"])
+	}
+
+	latest, err := LatestCheckpoint()
+	if err != nil {
+		t.Fatalf("LatestCheckpoint failed: %v", err)
+	}
+	if latest != second {
+		t.Errorf("Expected latest checkpoint %s, got %s", second, latest)
+	}
+}
@@ -1,7 +1,10 @@
 // Package model - configuration
 package model
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 type Config struct {
 	MoveDuplicates        bool
@@ -10,19 +13,45 @@ type Config struct {
 	Silent                bool
 	LogFilePath           string
 	DetectTransparentPNGs bool
+	DetectScreenshots     bool
+	TransparencyThreshold float64 // share of non-opaque pixels for -t, 0 means DefaultTransparencyThreshold
+	ConsolidateCopies     bool
 	MaxHashFileSizeMB     int64
+	ClassifyWorkers       int           // files inspected in parallel, 0 means DefaultWorkerCount
+	MoveWorkers           int           // parallel moves, 0 means 1
+	VerifyCopies          bool          // hash files copied between filesystems before the source is removed
+	ConflictPolicy        string        // -C, replaces conflict_policy of the extension config for this run
+	UseTrash              bool          // files GoSorter replaces or removes go to the Trash instead of being deleted
+	QuietPeriod           time.Duration // files modified more recently are left for the next run
+	CheckpointFile        string        // duplicate detection progress is saved here, empty disables checkpoints
+	Resume                bool          // continue from CheckpointFile instead of starting over
+	Force                 bool          // sort home, system, drive and project roots too
 	MaxHashFileSize       int64
 }
 
 const (
 	DefaultWorkerCount = 8
 	DefaultBufferSize  = 100
+
+	DefaultTransparencyThreshold = 0.5
 )
 
 func (c *Config) Validate() error {
 	if c.Verbose && c.Silent {
 		return fmt.Errorf("verbose and silent modes cannot be enabled simultaneously, otherwise, GoSorter might take a selfie")
 	}
+	if c.TransparencyThreshold < 0 || c.TransparencyThreshold >= 1 {
+		return fmt.Errorf("transparency threshold must be between 0 and 1")
+	}
+	if c.ClassifyWorkers < 0 || c.MoveWorkers < 0 {
+		return fmt.Errorf("worker counts must be >= 0")
+	}
+	if c.ConflictPolicy != "" && !ValidConflictPolicy(c.ConflictPolicy) {
+		return fmt.Errorf("unknown conflict policy %q", c.ConflictPolicy)
+	}
+	if c.QuietPeriod < 0 {
+		return fmt.Errorf("quiet period must be >= 0")
+	}
 	if c.MaxHashFileSizeMB < 0 {
 		return fmt.Errorf("max hash file size must be >= 0")
 	}
@@ -70,6 +70,14 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "invalid config - transparency threshold out of range",
+			config: Config{
+				DetectTransparentPNGs: true,
+				TransparencyThreshold: 1.5,
+			},
+			wantErr: true,
+		},
 		{
 			name: "invalid config - both verbose and silent",
 			config: Config{
@@ -0,0 +1,85 @@
+// Package model - conflict policies
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// what happens when a file with the same name already exists at the destination
+const (
+	ConflictRename           = "rename"             // identical files are replaced, different ones renamed with the rename pattern
+	ConflictSkip             = "skip"               // the incoming file stays where it is
+	ConflictOverwriteIfNewer = "overwrite_if_newer" // the incoming file replaces an older one, otherwise it stays where it is
+	ConflictKeepLarger       = "keep_larger"        // the incoming file replaces a smaller one, otherwise it stays where it is
+	ConflictDuplicates       = "duplicates"         // identical incoming files go to Duplicates, different ones are renamed
+	ConflictPrompt           = "prompt"             // ask for every conflict
+
+	// ConflictOverwrite - only as an answer to the prompt
+	ConflictOverwrite = "overwrite"
+)
+
+// DefaultConflictRenamePattern - "report.pdf" -> "report(1).pdf"
+const DefaultConflictRenamePattern = "{name}({n}){ext}"
+
+// conflictPatternTokens - tokens of conflict_rename_pattern
+var conflictPatternTokens = map[string]string{
+	"name": "file name without extension",
+	"n":    "counter, 1 for the first conflict",
+	"ext":  "extension with the dot",
+}
+
+// Conflict - how one move resolves an existing destination
+type Conflict struct {
+	Policy        string
+	RenamePattern string
+	// DuplicatesFolder - where ConflictDuplicates moves identical files
+	DuplicatesFolder string
+	// Ask - answers ConflictPrompt with skip, rename or overwrite. without it prompts fall back to rename
+	Ask func(src, dst string) string
+}
+
+// ValidConflictPolicy - one of the configurable policies
+func ValidConflictPolicy(policy string) bool {
+	switch policy {
+	case ConflictRename, ConflictSkip, ConflictOverwriteIfNewer, ConflictKeepLarger, ConflictDuplicates, ConflictPrompt:
+		return true
+	}
+	return false
+}
+
+// ConflictFor - policy of an extension, override (-C) replaces the global conflict_policy
+// but not the per-extension ones
+func (ec *ExtensionConfig) ConflictFor(extension, override string) Conflict {
+	conflict := Conflict{Policy: ec.ConflictPolicy, RenamePattern: ec.ConflictRenamePattern, DuplicatesFolder: ec.DuplicatesFolder}
+	if override != "" {
+		conflict.Policy = override
+	}
+	if policy, ok := ec.ConflictPolicies[extension]; ok {
+		conflict.Policy = policy
+	}
+	if conflict.Policy == "" {
+		conflict.Policy = ConflictRename
+	}
+	if conflict.RenamePattern == "" {
+		conflict.RenamePattern = DefaultConflictRenamePattern
+	}
+	if conflict.DuplicatesFolder == "" {
+		conflict.DuplicatesFolder = DefaultExtensionConfig().DuplicatesFolder
+	}
+	return conflict
+}
+
+// validateConflictPattern - needs {n}, or every conflict would get the same name
+func validateConflictPattern(pattern string) error {
+	if err := validateTokens(pattern, conflictPatternTokens); err != nil {
+		return err
+	}
+	if !strings.Contains(pattern, "{n}") {
+		return fmt.Errorf("must contain {n}")
+	}
+	if strings.ContainsAny(pattern, `/\`) {
+		return fmt.Errorf("must not contain path separators")
+	}
+	return nil
+}
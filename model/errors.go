@@ -1,7 +1,10 @@
 // Package model - errors (custom errors)
 package model
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type FileError struct {
 	Op   string
@@ -50,3 +53,20 @@ func NewMoveError(src, dest string, err error) *MoveError {
 		Dest: dest,
 	}
 }
+
+// ConfigError - every problem found in a configuration, one per line
+type ConfigError struct {
+	Problems []error
+}
+
+func (e *ConfigError) Error() string {
+	lines := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		lines[i] = p.Error()
+	}
+	return strings.Join(lines, "\n  ")
+}
+
+func (e *ConfigError) Unwrap() []error {
+	return e.Problems
+}
@@ -3,15 +3,114 @@ package model
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// photo layouts, subfolders under the pictures folder
+const (
+	PhotoLayoutFlat       = "flat"        // Pictures/
+	PhotoLayoutDate       = "date"        // Pictures/2024/2024-07
+	PhotoLayoutCamera     = "camera"      // Pictures/<camera model>
+	PhotoLayoutCameraDate = "camera-date" // Pictures/<camera model>/2024/2024-07
+)
+
+// music layouts, subfolders and file names under the music folder
+const (
+	MusicLayoutFlat        = "flat"         // Music/
+	MusicLayoutArtistAlbum = "artist-album" // Music/<Artist>/<Album>/<track> - <title>.ext
+)
+
+// ebook layouts, subfolders under the ebooks folder
+const (
+	EbookLayoutFlat   = "flat"   // Ebooks/
+	EbookLayoutAuthor = "author" // Ebooks/<Author>/
+)
+
+// where date tokens ({yyyy}, {mm}, {dd}) in folder names take their date from
+const (
+	DateSourceMtime    = "mtime"    // last modification
+	DateSourceCtime    = "ctime"    // creation (birth time on macOS/Windows, change time on linux)
+	DateSourceFilename = "filename" // "2024-03-01_statement.pdf", "IMG_20240301_..." falls back to mtime
+)
+
+// what happens to hidden files, junk files and symlinks. devices, pipes and sockets are always skipped
+const (
+	EntrySkip   = "skip"   // left where they are
+	EntrySort   = "sort"   // sorted by extension like any other file, symlinks are moved as links
+	EntryDelete = "delete" // junk files only, deleted (moved to the Trash with -tr)
+)
+
+// junkFileNames - metadata file managers leave behind, lowercase
+var junkFileNames = map[string]bool{
+	".ds_store":   true, // macOS Finder
+	"thumbs.db":   true, // Windows Explorer thumbnails
+	"desktop.ini": true, // Windows Explorer folder settings
+}
+
+// IsJunkFile - .DS_Store, Thumbs.db, desktop.ini in any case
+func IsJunkFile(name string) bool {
+	return junkFileNames[strings.ToLower(name)]
+}
+
+// VideoRule - first matching rule picks the folder for a video, zero values are ignored
+type VideoRule struct {
+	Folder      string  `json:"folder"`       // e.g. "Videos/4K", "Videos/{yyyy}" (recording date)
+	MinWidth    int     `json:"min_width"`    // pixels
+	MinHeight   int     `json:"min_height"`   // pixels
+	MaxHeight   int     `json:"max_height"`   // pixels
+	MinDuration float64 `json:"min_duration"` // seconds
+	MaxDuration float64 `json:"max_duration"` // seconds, exclusive
+}
+
+// DocumentRule - first matching rule picks the folder for a PDF or office document.
+// matches are case-insensitive substrings of the metadata, empty fields are ignored
+type DocumentRule struct {
+	Folder   string `json:"folder"`   // e.g. "Scans", "Work/{yyyy}" (creation date)
+	Producer string `json:"producer"` // PDF Producer/Creator, office application
+	Author   string `json:"author"`
+	Title    string `json:"title"`
+}
+
+// AnyExtension - sidecar primary wildcard, the sidecar is named after the full file name ("file.tar.gz.sig")
+const AnyExtension = "*"
+
+var (
+	rawExtensions         = []string{".raw", ".cr2", ".cr3", ".nef", ".arw", ".raf", ".orf", ".rw2"}
+	videoSidecarPrimaries = []string{".mkv", ".mp4", ".avi", ".mov", ".m4v", ".webm", ".mpg", ".mpeg"}
 )
 
 type ExtensionConfig struct {
-	ExtensionToFolder      map[string]string `json:"extension_to_folder"`
-	ArchiveExtractedFolder string            `json:"archives_extracted_folder"`
-	DuplicatesFolder       string            `json:"duplicates_folder"`
-	TransparentPNGFolder   string            `json:"transparent_png_folder"`
+	ExtensionToFolder      map[string]string   `json:"extension_to_folder"`
+	ArchiveExtractedFolder string              `json:"archives_extracted_folder"`
+	DuplicatesFolder       string              `json:"duplicates_folder"`
+	TransparentPNGFolder   string              `json:"transparent_png_folder"`
+	VersionsFolder         string              `json:"versions_folder"`
+	ScreenshotsFolder      string              `json:"screenshots_folder"`
+	ScreenRecordingsFolder string              `json:"screen_recordings_folder"`
+	PhotoLayout            string              `json:"photo_layout"`
+	MusicLayout            string              `json:"music_layout"`
+	VideoRules             []VideoRule         `json:"video_rules"`
+	EbookLayout            string              `json:"ebook_layout"`
+	EbookRename            bool                `json:"ebook_rename"`
+	DocumentRules          []DocumentRule      `json:"document_rules"`
+	Sidecars               map[string][]string `json:"sidecars"`
+	DateSubfolders         string              `json:"date_subfolders"`
+	DateSource             string              `json:"date_source"`
+	ConflictPolicy         string              `json:"conflict_policy"`
+	ConflictPolicies       map[string]string   `json:"conflict_policies"` // extension -> policy
+	ConflictRenamePattern  string              `json:"conflict_rename_pattern"`
+	HiddenFiles            string              `json:"hidden_files"`          // skip or sort dotfiles
+	JunkFiles              string              `json:"junk_files"`            // skip, sort or delete junk files
+	Symlinks               string              `json:"symlinks"`              // skip or sort symlinks
+	RewriteSymlinks        bool                `json:"rewrite_symlinks"`      // relative symlinks keep pointing to their target when moved
+	AllowOutsideFolders    bool                `json:"allow_outside_folders"` // absolute folders and ".." may leave the sorted directory
+
+	DestinationTemplate  DestinationTemplate            `json:"destination_template"`
+	DestinationTemplates map[string]DestinationTemplate `json:"destination_templates"`
 }
 
 func DefaultExtensionConfig() *ExtensionConfig {
@@ -53,6 +152,7 @@ func DefaultExtensionConfig() *ExtensionConfig {
 			".mobi": "Ebooks",
 			".epub": "Ebooks",
 			".azw3": "Ebooks",
+			".azw":  "Ebooks",
 
 			// Executables/Apps
 			".apk": "AndroidApps",
@@ -68,6 +168,14 @@ func DefaultExtensionConfig() *ExtensionConfig {
 			".jpeg": "Pictures",
 			".png":  "Pictures",
 			".raw":  "RawImages",
+			".cr2":  "RawImages",
+			".cr3":  "RawImages",
+			".nef":  "RawImages",
+			".arw":  "RawImages",
+			".dng":  "RawImages",
+			".raf":  "RawImages",
+			".orf":  "RawImages",
+			".rw2":  "RawImages",
 			".svg":  "SVGs",
 			".tiff": "Pictures",
 			".tif":  "Pictures",
@@ -102,6 +210,8 @@ func DefaultExtensionConfig() *ExtensionConfig {
 			".mpg":  "Videos",
 			".mpeg": "Videos",
 			".webm": "Videos",
+			".mov":  "Videos",
+			".m4v":  "Videos",
 
 			// Virtual Machines
 			".ova": "VirtualMachines",
@@ -118,9 +228,33 @@ func DefaultExtensionConfig() *ExtensionConfig {
 			// PDFs
 			".pdf": "PDFs",
 		},
+		Sidecars: map[string][]string{
+			".xmp":    append([]string{".jpg", ".jpeg", ".heic", ".tif", ".tiff"}, rawExtensions...),
+			".dng":    rawExtensions,
+			".srt":    videoSidecarPrimaries,
+			".vtt":    videoSidecarPrimaries,
+			".nfo":    videoSidecarPrimaries,
+			".cue":    {".flac", ".wav"},
+			".log":    {".flac"},
+			".sig":    {AnyExtension},
+			".sha256": {AnyExtension},
+			".asc":    {AnyExtension},
+		},
 		ArchiveExtractedFolder: "Archives-Extracted",
 		DuplicatesFolder:       "Duplicates",
 		TransparentPNGFolder:   "PNGs",
+		VersionsFolder:         "Versions",
+		ScreenshotsFolder:      "Screenshots",
+		ScreenRecordingsFolder: "Screen Recordings",
+		PhotoLayout:            PhotoLayoutFlat,
+		MusicLayout:            MusicLayoutFlat,
+		EbookLayout:            EbookLayoutFlat,
+		DateSource:             DateSourceMtime,
+		ConflictPolicy:         ConflictRename,
+		ConflictRenamePattern:  DefaultConflictRenamePattern,
+		HiddenFiles:            EntrySort,
+		JunkFiles:              EntrySkip,
+		Symlinks:               EntrySort,
 	}
 }
 
@@ -129,9 +263,145 @@ func (ec *ExtensionConfig) GetTargetFolder(extension string) (string, bool) {
 	return folder, exists
 }
 
+// TemplateFor - per-extension template, or the global one
+func (ec *ExtensionConfig) TemplateFor(extension string) DestinationTemplate {
+	if t, ok := ec.DestinationTemplates[extension]; ok {
+		return t
+	}
+	return ec.DestinationTemplate
+}
+
+// Validate - checks folder names, templates and date tokens before anything is moved.
+// every problem is reported, not just the first one
+func (ec *ExtensionConfig) Validate() error {
+	problems := ec.validateFolders()
+	exts := make([]string, 0, len(ec.ExtensionToFolder))
+	for ext := range ec.ExtensionToFolder {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	for _, ext := range exts {
+		if err := validateTokens(ec.ExtensionToFolder[ext], dateTokens); err != nil {
+			problems = append(problems, fmt.Errorf("extension_to_folder[%s]: %w", ext, err))
+		}
+	}
+	if err := validateTokens(ec.DateSubfolders, dateTokens); err != nil {
+		problems = append(problems, fmt.Errorf("date_subfolders: %w", err))
+	}
+	switch ec.DateSource {
+	case "", DateSourceMtime, DateSourceCtime, DateSourceFilename:
+	default:
+		problems = append(problems, fmt.Errorf("date_source: unknown value %q", ec.DateSource))
+	}
+	switch ec.PhotoLayout {
+	case "", PhotoLayoutFlat, PhotoLayoutDate, PhotoLayoutCamera, PhotoLayoutCameraDate:
+	default:
+		problems = append(problems, fmt.Errorf("photo_layout: unknown value %q", ec.PhotoLayout))
+	}
+	switch ec.MusicLayout {
+	case "", MusicLayoutFlat, MusicLayoutArtistAlbum:
+	default:
+		problems = append(problems, fmt.Errorf("music_layout: unknown value %q", ec.MusicLayout))
+	}
+	switch ec.EbookLayout {
+	case "", EbookLayoutFlat, EbookLayoutAuthor:
+	default:
+		problems = append(problems, fmt.Errorf("ebook_layout: unknown value %q", ec.EbookLayout))
+	}
+	switch ec.HiddenFiles {
+	case "", EntrySkip, EntrySort:
+	default:
+		problems = append(problems, fmt.Errorf("hidden_files: unknown value %q", ec.HiddenFiles))
+	}
+	switch ec.JunkFiles {
+	case "", EntrySkip, EntrySort, EntryDelete:
+	default:
+		problems = append(problems, fmt.Errorf("junk_files: unknown value %q", ec.JunkFiles))
+	}
+	switch ec.Symlinks {
+	case "", EntrySkip, EntrySort:
+	default:
+		problems = append(problems, fmt.Errorf("symlinks: unknown value %q", ec.Symlinks))
+	}
+	for i, rule := range ec.VideoRules {
+		if rule.Folder == "" {
+			problems = append(problems, fmt.Errorf("video_rules[%d]: folder is required", i))
+		}
+		if err := validateTokens(rule.Folder, dateTokens); err != nil {
+			problems = append(problems, fmt.Errorf("video_rules[%d]: %w", i, err))
+		}
+	}
+	for i, rule := range ec.DocumentRules {
+		if rule.Folder == "" {
+			problems = append(problems, fmt.Errorf("document_rules[%d]: folder is required", i))
+		}
+		if rule.Producer == "" && rule.Author == "" && rule.Title == "" {
+			problems = append(problems, fmt.Errorf("document_rules[%d]: needs at least one of producer, author or title", i))
+		}
+		if err := validateTokens(rule.Folder, dateTokens); err != nil {
+			problems = append(problems, fmt.Errorf("document_rules[%d]: %w", i, err))
+		}
+	}
+	exts = exts[:0]
+	for ext := range ec.Sidecars {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	for _, ext := range exts {
+		if !strings.HasPrefix(ext, ".") || ext != strings.ToLower(ext) {
+			problems = append(problems, fmt.Errorf("sidecars[%s]: must be a lowercase extension like \".srt\"", ext))
+		}
+		for _, primary := range ec.Sidecars[ext] {
+			if primary != AnyExtension && (!strings.HasPrefix(primary, ".") || primary != strings.ToLower(primary)) {
+				problems = append(problems, fmt.Errorf("sidecars[%s]: %q must be a lowercase extension or %q", ext, primary, AnyExtension))
+			}
+		}
+	}
+	if ec.ConflictPolicy != "" && !ValidConflictPolicy(ec.ConflictPolicy) {
+		problems = append(problems, fmt.Errorf("conflict_policy: unknown value %q", ec.ConflictPolicy))
+	}
+	exts = exts[:0]
+	for ext := range ec.ConflictPolicies {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	for _, ext := range exts {
+		if !strings.HasPrefix(ext, ".") || ext != strings.ToLower(ext) {
+			problems = append(problems, fmt.Errorf("conflict_policies[%s]: must be a lowercase extension like \".jpg\"", ext))
+		}
+		if !ValidConflictPolicy(ec.ConflictPolicies[ext]) {
+			problems = append(problems, fmt.Errorf("conflict_policies[%s]: unknown value %q", ext, ec.ConflictPolicies[ext]))
+		}
+	}
+	if ec.ConflictRenamePattern != "" {
+		if err := validateConflictPattern(ec.ConflictRenamePattern); err != nil {
+			problems = append(problems, fmt.Errorf("conflict_rename_pattern: %w", err))
+		}
+	}
+	if err := ec.DestinationTemplate.Validate(); err != nil {
+		problems = append(problems, fmt.Errorf("destination_template: %w", err))
+	}
+	exts = exts[:0]
+	for ext := range ec.DestinationTemplates {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	for _, ext := range exts {
+		if err := ec.DestinationTemplates[ext].Validate(); err != nil {
+			problems = append(problems, fmt.Errorf("destination_templates[%s]: %w", ext, err))
+		}
+	}
+	if len(problems) > 0 {
+		return &ConfigError{Problems: problems}
+	}
+	return nil
+}
+
+// LoadExtensionConfig - the user config, the defaults when there is none or it cannot be read.
+// use ReadExtensionConfig to find out why
 func LoadExtensionConfig() *ExtensionConfig {
 	// user config first
-	if config, err := loadUserExtensionConfig(); err == nil {
+	if config, err := loadUserExtensionConfig(); err == nil && config != nil {
 		return config
 	}
 
@@ -139,18 +409,34 @@ func LoadExtensionConfig() *ExtensionConfig {
 	return DefaultExtensionConfig()
 }
 
-// config ~/.config/GoSorter/extension.json
+// ReadExtensionConfig - the validated user config, the defaults when there is no config file.
+// a config file that cannot be read or parsed is an error, not a reason to sort with defaults
+func ReadExtensionConfig() (*ExtensionConfig, error) {
+	config, err := loadUserExtensionConfig()
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return DefaultExtensionConfig(), nil
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// config ~/.config/GoSorter/extension.json, nil without a config file
 // to do: test on linux. already tested on mac
 func loadUserExtensionConfig() (*ExtensionConfig, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return nil, err
+		return nil, nil
 	}
 
 	configPath := filepath.Join(homeDir, ".config", "GoSorter", "extension.json")
 
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return nil, err
+		return nil, nil
 	}
 	configPath = filepath.Clean(configPath)
 	data, err := os.ReadFile(configPath)
@@ -160,7 +446,7 @@ func loadUserExtensionConfig() (*ExtensionConfig, error) {
 
 	var config ExtensionConfig
 	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%s: %w", configPath, err)
 	}
 
 	return mergeWithDefaults(&config), nil
@@ -183,6 +469,20 @@ func mergeWithDefaults(userConfig *ExtensionConfig) *ExtensionConfig {
 		userConfig.ExtensionToFolder = merged
 	}
 
+	if userConfig.Sidecars == nil {
+		userConfig.Sidecars = defaultConfig.Sidecars
+	} else {
+		// an empty list turns a default sidecar off
+		merged := make(map[string][]string)
+		for ext, primaries := range defaultConfig.Sidecars {
+			merged[ext] = primaries
+		}
+		for ext, primaries := range userConfig.Sidecars {
+			merged[ext] = primaries
+		}
+		userConfig.Sidecars = merged
+	}
+
 	if userConfig.ArchiveExtractedFolder == "" {
 		userConfig.ArchiveExtractedFolder = defaultConfig.ArchiveExtractedFolder
 	}
@@ -192,6 +492,42 @@ func mergeWithDefaults(userConfig *ExtensionConfig) *ExtensionConfig {
 	if userConfig.TransparentPNGFolder == "" {
 		userConfig.TransparentPNGFolder = defaultConfig.TransparentPNGFolder
 	}
+	if userConfig.VersionsFolder == "" {
+		userConfig.VersionsFolder = defaultConfig.VersionsFolder
+	}
+	if userConfig.ScreenshotsFolder == "" {
+		userConfig.ScreenshotsFolder = defaultConfig.ScreenshotsFolder
+	}
+	if userConfig.ScreenRecordingsFolder == "" {
+		userConfig.ScreenRecordingsFolder = defaultConfig.ScreenRecordingsFolder
+	}
+	if userConfig.PhotoLayout == "" {
+		userConfig.PhotoLayout = defaultConfig.PhotoLayout
+	}
+	if userConfig.MusicLayout == "" {
+		userConfig.MusicLayout = defaultConfig.MusicLayout
+	}
+	if userConfig.EbookLayout == "" {
+		userConfig.EbookLayout = defaultConfig.EbookLayout
+	}
+	if userConfig.DateSource == "" {
+		userConfig.DateSource = defaultConfig.DateSource
+	}
+	if userConfig.ConflictPolicy == "" {
+		userConfig.ConflictPolicy = defaultConfig.ConflictPolicy
+	}
+	if userConfig.ConflictRenamePattern == "" {
+		userConfig.ConflictRenamePattern = defaultConfig.ConflictRenamePattern
+	}
+	if userConfig.HiddenFiles == "" {
+		userConfig.HiddenFiles = defaultConfig.HiddenFiles
+	}
+	if userConfig.JunkFiles == "" {
+		userConfig.JunkFiles = defaultConfig.JunkFiles
+	}
+	if userConfig.Symlinks == "" {
+		userConfig.Symlinks = defaultConfig.Symlinks
+	}
 
 	return userConfig
 }
@@ -2,7 +2,10 @@
 package model
 
 import (
+	"errors"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -40,6 +43,11 @@ func TestDefaultExtensionConfig(t *testing.T) {
 	if config.TransparentPNGFolder != "PNGs" {
 		t.Errorf("Expected TransparentPNGFolder to be PNGs, got %s", config.TransparentPNGFolder)
 	}
+
+	// dotfiles and symlinks were always sorted, skipping them is opt-in
+	if config.HiddenFiles != EntrySort || config.Symlinks != EntrySort {
+		t.Errorf("Expected hidden files and symlinks to be sorted by default, got %s and %s", config.HiddenFiles, config.Symlinks)
+	}
 }
 
 func TestGetTargetFolder(t *testing.T) {
@@ -92,6 +100,65 @@ func TestLoadExtensionConfig_NoUserConfig(t *testing.T) {
 	}
 }
 
+func TestReadExtensionConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string // extension.json, none when empty
+		wantErr string
+	}{
+		{name: "no config file"},
+		{name: "valid", content: `{"extension_to_folder": {".custom": "Custom"}}`},
+		{name: "malformed", content: `{"extension_to_folder": {".custom": "Custom"`, wantErr: "extension.json: unexpected end of JSON input"},
+		{name: "invalid", content: `{"conflict_policy": "sometimes"}`, wantErr: "conflict_policy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir, err := os.MkdirTemp("", "gosorter_config_test")
+			if err != nil {
+				t.Fatalf("Failed to create temp dir: %v", err)
+			}
+			defer func() {
+				if err := os.RemoveAll(tempDir); err != nil {
+					t.Fatalf("Failed to remove temp dir: %v", err)
+				}
+			}()
+			originalHome := os.Getenv("HOME")
+			if err := os.Setenv("HOME", tempDir); err != nil {
+				t.Fatalf("Failed to set HOME: %v", err)
+			}
+			defer func() {
+				if err := os.Setenv("HOME", originalHome); err != nil {
+					t.Fatalf("Failed to restore HOME: %v", err)
+				}
+			}()
+			if tt.content != "" {
+				configDir := filepath.Join(tempDir, ".config", "GoSorter")
+				if err := os.MkdirAll(configDir, 0750); err != nil {
+					t.Fatalf("Failed to create config dir: %v", err)
+				}
+				if err := os.WriteFile(filepath.Join(configDir, "extension.json"), []byte(tt.content), 0600); err != nil {
+					t.Fatalf("Failed to write config: %v", err)
+				}
+			}
+
+			config, err := ReadExtensionConfig()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadExtensionConfig failed: %v", err)
+			}
+			if config.ExtensionToFolder[".pdf"] != "PDFs" {
+				t.Errorf("Expected .pdf to map to PDFs (default), got %s", config.ExtensionToFolder[".pdf"])
+			}
+		})
+	}
+}
+
 func TestSaveAndLoadExtensionConfig(t *testing.T) {
 	tempDir, err := os.MkdirTemp("", "gosorter_config_test")
 	if err != nil {
@@ -168,3 +235,242 @@ func TestMergeWithDefaults(t *testing.T) {
 		t.Errorf("Expected ArchiveExtractedFolder to be Archives-Extracted (default), got %s", merged.ArchiveExtractedFolder)
 	}
 }
+
+func TestExtensionConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(ec *ExtensionConfig)
+		wantErr bool
+	}{
+		{
+			name:    "defaults",
+			modify:  func(ec *ExtensionConfig) {},
+			wantErr: false,
+		},
+		{
+			name: "valid templates",
+			modify: func(ec *ExtensionConfig) {
+				ec.DestinationTemplate = DestinationTemplate{Folder: "{category}/{yyyy}", FileName: "{yyyy}-{mm}-{dd}_{name}"}
+				ec.DestinationTemplates = map[string]DestinationTemplate{
+					".jpg": {Folder: "Pictures/{exif.model}/{size_bucket}", FileName: "{hash8}"},
+				}
+			},
+			wantErr: false,
+		},
+		{
+			name: "date tokens in extension mapping",
+			modify: func(ec *ExtensionConfig) {
+				ec.ExtensionToFolder[".pdf"] = "PDFs/{yyyy}/{mm}"
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown token",
+			modify: func(ec *ExtensionConfig) {
+				ec.DestinationTemplate = DestinationTemplate{Folder: "{category}/{year}"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "non-date token in extension mapping",
+			modify: func(ec *ExtensionConfig) {
+				ec.ExtensionToFolder[".pdf"] = "PDFs/{name}"
+			},
+			wantErr: true,
+		},
+		{
+			name: "document rule without conditions",
+			modify: func(ec *ExtensionConfig) {
+				ec.DocumentRules = []DocumentRule{{Folder: "Scans"}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "sidecar extension without dot",
+			modify: func(ec *ExtensionConfig) {
+				ec.Sidecars["srt"] = []string{".mkv"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "unbalanced braces",
+			modify: func(ec *ExtensionConfig) {
+				ec.DestinationTemplates = map[string]DestinationTemplate{".pdf": {FileName: "{name"}}
+			},
+			wantErr: true,
+		},
+		{
+			name: "path separator in filename",
+			modify: func(ec *ExtensionConfig) {
+				ec.DestinationTemplate = DestinationTemplate{FileName: "{yyyy}/{name}"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown date source",
+			modify: func(ec *ExtensionConfig) {
+				ec.DateSource = "atime"
+			},
+			wantErr: true,
+		},
+		{
+			name: "conflict policies",
+			modify: func(ec *ExtensionConfig) {
+				ec.ConflictPolicy = ConflictSkip
+				ec.ConflictPolicies = map[string]string{".jpg": ConflictDuplicates}
+				ec.ConflictRenamePattern = "{name} ({n}){ext}"
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown conflict policy",
+			modify: func(ec *ExtensionConfig) {
+				ec.ConflictPolicies = map[string]string{".jpg": "overwrite"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "rename pattern without counter",
+			modify: func(ec *ExtensionConfig) {
+				ec.ConflictRenamePattern = "{name}_copy{ext}"
+			},
+			wantErr: true,
+		},
+		{
+			name: "entry policies",
+			modify: func(ec *ExtensionConfig) {
+				ec.HiddenFiles = EntrySort
+				ec.JunkFiles = EntryDelete
+				ec.Symlinks = EntrySort
+				ec.RewriteSymlinks = true
+			},
+			wantErr: false,
+		},
+		{
+			name: "delete hidden files",
+			modify: func(ec *ExtensionConfig) {
+				ec.HiddenFiles = EntryDelete
+			},
+			wantErr: true,
+		},
+		{
+			name: "folder outside the directory",
+			modify: func(ec *ExtensionConfig) {
+				ec.ExtensionToFolder[".pdf"] = "../../etc"
+			},
+			wantErr: true,
+		},
+		{
+			name: "absolute folder",
+			modify: func(ec *ExtensionConfig) {
+				ec.ExtensionToFolder[".pdf"] = "/etc"
+			},
+			wantErr: true,
+		},
+		{
+			name: "windows absolute folder",
+			modify: func(ec *ExtensionConfig) {
+				ec.DuplicatesFolder = `C:\\Windows`
+			},
+			wantErr: true,
+		},
+		{
+			name: "outside folders allowed",
+			modify: func(ec *ExtensionConfig) {
+				ec.AllowOutsideFolders = true
+				ec.ExtensionToFolder[".pdf"] = "../Sorted/PDFs"
+			},
+			wantErr: false,
+		},
+		{
+			name: "date subfolders outside the folder",
+			modify: func(ec *ExtensionConfig) {
+				ec.AllowOutsideFolders = true
+				ec.DateSubfolders = "../{yyyy}"
+			},
+			wantErr: true,
+		},
+		{
+			name: "folder inside after ..",
+			modify: func(ec *ExtensionConfig) {
+				ec.ExtensionToFolder[".pdf"] = "Docs/../PDFs"
+			},
+			wantErr: false,
+		},
+		{
+			name: "sorted directory itself",
+			modify: func(ec *ExtensionConfig) {
+				ec.ExtensionToFolder[".pdf"] = "."
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty folder",
+			modify: func(ec *ExtensionConfig) {
+				ec.ExtensionToFolder[".pdf"] = " "
+			},
+			wantErr: true,
+		},
+		{
+			name: "reserved name",
+			modify: func(ec *ExtensionConfig) {
+				ec.ExtensionToFolder[".pdf"] = "Docs/aux.pdf"
+			},
+			wantErr: true,
+		},
+		{
+			name: "GoSorter file name",
+			modify: func(ec *ExtensionConfig) {
+				ec.VersionsFolder = ".gosorterignore"
+			},
+			wantErr: true,
+		},
+		{
+			name: "trailing dot",
+			modify: func(ec *ExtensionConfig) {
+				ec.ScreenshotsFolder = "Shots."
+			},
+			wantErr: true,
+		},
+		{
+			name: "folders differing in case",
+			modify: func(ec *ExtensionConfig) {
+				ec.ExtensionToFolder[".png"] = "pictures/PNG"
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultExtensionConfig()
+			tt.modify(config)
+			err := config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ExtensionConfig.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExtensionConfig_ValidateReportsAllFolders(t *testing.T) {
+	config := DefaultExtensionConfig()
+	config.ExtensionToFolder[".pdf"] = "../PDFs"
+	config.ExtensionToFolder[".txt"] = "/tmp/Text"
+	config.ExtensionToFolder[".png"] = "CON"
+	config.DateSource = "atime"
+
+	err := config.Validate()
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) {
+		t.Fatalf("Expected a ConfigError, got %v", err)
+	}
+	if len(configErr.Problems) != 4 {
+		t.Errorf("Expected 4 problems, got %d: %v", len(configErr.Problems), err)
+	}
+	for _, key := range []string{"extension_to_folder[.pdf]", "extension_to_folder[.txt]", "extension_to_folder[.png]", "date_source"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("Expected %s in %q", key, err)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+// Package model - folder names
+package model
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// ConfiguredFolder - a folder name from the extension configuration and the key it was set with
+type ConfiguredFolder struct {
+	Key      string // e.g. "extension_to_folder[.pdf]", "video_rules[2].folder"
+	Folder   string
+	Required bool // empty is an error, the others are optional
+	Subpath  bool // appended below another folder, may never leave it
+}
+
+// Folders - every folder name in the configuration, in a stable order
+func (ec *ExtensionConfig) Folders() []ConfiguredFolder {
+	var folders []ConfiguredFolder
+	exts := make([]string, 0, len(ec.ExtensionToFolder))
+	for ext := range ec.ExtensionToFolder {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	for _, ext := range exts {
+		folders = append(folders, ConfiguredFolder{Key: fmt.Sprintf("extension_to_folder[%s]", ext), Folder: ec.ExtensionToFolder[ext], Required: true})
+	}
+	for _, f := range []ConfiguredFolder{
+		{Key: "archives_extracted_folder", Folder: ec.ArchiveExtractedFolder},
+		{Key: "duplicates_folder", Folder: ec.DuplicatesFolder},
+		{Key: "transparent_png_folder", Folder: ec.TransparentPNGFolder},
+		{Key: "versions_folder", Folder: ec.VersionsFolder},
+		{Key: "screenshots_folder", Folder: ec.ScreenshotsFolder},
+		{Key: "screen_recordings_folder", Folder: ec.ScreenRecordingsFolder},
+	} {
+		f.Required = true
+		folders = append(folders, f)
+	}
+	for i, rule := range ec.VideoRules {
+		folders = append(folders, ConfiguredFolder{Key: fmt.Sprintf("video_rules[%d].folder", i), Folder: rule.Folder})
+	}
+	for i, rule := range ec.DocumentRules {
+		folders = append(folders, ConfiguredFolder{Key: fmt.Sprintf("document_rules[%d].folder", i), Folder: rule.Folder})
+	}
+	folders = append(folders, ConfiguredFolder{Key: "date_subfolders", Folder: ec.DateSubfolders, Subpath: true})
+	folders = append(folders, ConfiguredFolder{Key: "destination_template.folder", Folder: ec.DestinationTemplate.Folder})
+	exts = exts[:0]
+	for ext := range ec.DestinationTemplates {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	for _, ext := range exts {
+		folders = append(folders, ConfiguredFolder{Key: fmt.Sprintf("destination_templates[%s].folder", ext), Folder: ec.DestinationTemplates[ext].Folder})
+	}
+	return folders
+}
+
+// reservedNamePattern - device names Windows refuses as file or folder names, with any extension
+var reservedNamePattern = regexp.MustCompile(`(?i)^(con|prn|aux|nul|com[0-9]|lpt[0-9])(\..*)?$`)
+
+// validateFolders - folder names are joined with the sorted directory, so "../../etc" or "/etc"
+// would move files out of it. these need allow_outside_folders, and names that cannot be created
+// on every system, or that only differ in case, are rejected
+func (ec *ExtensionConfig) validateFolders() []error {
+	var problems []error
+	seen := make(map[string]ConfiguredFolder) // lowercase path -> first folder using it
+	reported := make(map[string]bool)
+	for _, f := range ec.Folders() {
+		if strings.TrimSpace(f.Folder) == "" {
+			if f.Required {
+				problems = append(problems, fmt.Errorf("%s: folder name is empty", f.Key))
+			}
+			continue
+		}
+		if err := validateFolder(f.Folder, f.Subpath, ec.AllowOutsideFolders); err != nil {
+			problems = append(problems, fmt.Errorf("%s: %q %w", f.Key, f.Folder, err))
+			continue
+		}
+		if f.Subpath {
+			continue
+		}
+
+		// "Pictures" and "pictures/2024" end up in the same folder on macOS and Windows
+		path := ""
+		for _, segment := range folderSegments(f.Folder) {
+			path += segment + "/"
+			first, ok := seen[strings.ToLower(path)]
+			if !ok {
+				seen[strings.ToLower(path)] = ConfiguredFolder{Key: f.Key, Folder: path}
+				continue
+			}
+			if first.Folder != path && !reported[first.Key+f.Key] {
+				reported[first.Key+f.Key] = true
+				problems = append(problems, fmt.Errorf("%s: %q only differs in case from %q (%s), they are the same folder on macOS and Windows",
+					f.Key, strings.TrimSuffix(path, "/"), strings.TrimSuffix(first.Folder, "/"), first.Key))
+			}
+		}
+	}
+	return problems
+}
+
+// validateFolder - checks every segment of a folder name, template tokens are left for validateTokens
+func validateFolder(folder string, subpath, allowOutside bool) error {
+	if isAbsFolder(folder) {
+		if subpath {
+			return fmt.Errorf("must be a relative path")
+		}
+		if !allowOutside {
+			return fmt.Errorf("is an absolute path outside the sorted directory, set allow_outside_folders to use it")
+		}
+		if !filepath.IsAbs(folder) {
+			return fmt.Errorf("is not an absolute path on %s", runtime.GOOS)
+		}
+	}
+	depth, outside := 0, isAbsFolder(folder)
+	for i, segment := range folderSegments(folder) {
+		switch {
+		case segment == "..":
+			depth--
+			if depth < 0 && !outside {
+				if subpath {
+					return fmt.Errorf("leaves the folder it is appended to")
+				}
+				if !allowOutside {
+					return fmt.Errorf("leaves the sorted directory, set allow_outside_folders to use it")
+				}
+				outside = true
+			}
+			continue
+		case segment == ".":
+			continue
+		case i == 0 && outside && strings.HasSuffix(segment, ":"):
+			continue // "C:"
+		}
+		depth++
+		if strings.Contains(segment, "{") {
+			continue
+		}
+		if err := validateFolderName(segment); err != nil {
+			return err
+		}
+	}
+	if depth <= 0 && !subpath && !outside {
+		return fmt.Errorf("is the sorted directory itself")
+	}
+	return nil
+}
+
+// validateFolderName - a single path segment without tokens
+func validateFolderName(name string) error {
+	if reservedNamePattern.MatchString(name) {
+		return fmt.Errorf("uses the reserved name %q", name)
+	}
+	if strings.HasPrefix(strings.ToLower(name), ".gosorter") {
+		return fmt.Errorf("uses the name %q, reserved for GoSorter's own files", name)
+	}
+	if strings.HasSuffix(name, ".") || strings.HasSuffix(name, " ") {
+		return fmt.Errorf("has %q ending in a dot or space, which Windows removes", name)
+	}
+	for _, r := range name {
+		if r < 0x20 || r == 0x7f {
+			return fmt.Errorf("contains a control character")
+		}
+		if runtime.GOOS == "windows" && strings.ContainsRune(`<>:"|?*`, r) {
+			return fmt.Errorf("contains %q, which is not allowed in Windows folder names", r)
+		}
+	}
+	return nil
+}
+
+// folderSegments - both separators, so a config written on Windows is checked the same everywhere
+func folderSegments(folder string) []string {
+	return strings.FieldsFunc(folder, func(r rune) bool { return r == '/' || r == '\\' })
+}
+
+// isAbsFolder - "/srv", "\\server\share" and "C:\Users" on any system
+func isAbsFolder(folder string) bool {
+	if filepath.IsAbs(folder) || strings.HasPrefix(folder, "/") || strings.HasPrefix(folder, `\`) {
+		return true
+	}
+	return len(folder) >= 2 && folder[1] == ':' && (folder[0]|0x20 >= 'a' && folder[0]|0x20 <= 'z')
+}
@@ -0,0 +1,178 @@
+// Package model - ignore files
+package model
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFileName - ignore file in the sorted directory, never sorted itself
+const IgnoreFileName = ".gosorterignore"
+
+// IgnoreRules - gitignore patterns, the last matching pattern decides
+type IgnoreRules struct {
+	rules []ignoreRule
+}
+
+type ignoreRule struct {
+	pattern *regexp.Regexp
+	negate  bool // "!pattern" includes files again
+	dirOnly bool // "pattern/" only matches directories
+}
+
+// GlobalIgnorePath - ~/.config/GoSorter/ignore, applies to every directory
+func GlobalIgnorePath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".config", "GoSorter", "ignore"), nil
+}
+
+// LoadIgnore - the global ignore file, then folder's .gosorterignore, so patterns of the
+// directory win. missing files are no error
+func LoadIgnore(folder string) (*IgnoreRules, error) {
+	paths := []string{filepath.Join(folder, IgnoreFileName)}
+	if global, err := GlobalIgnorePath(); err == nil {
+		paths = append([]string{global}, paths...)
+	}
+
+	rules := &IgnoreRules{}
+	for _, path := range paths {
+		f, err := os.Open(filepath.Clean(path))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := ParseIgnore(f)
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		rules.rules = append(rules.rules, parsed.rules...)
+	}
+	return rules, nil
+}
+
+// ParseIgnore - gitignore syntax: # comments, !negation, trailing / for directories, a leading or
+// inner / anchors the pattern to the directory, *, ?, [a-z] and ** wildcards, \ escapes
+func ParseIgnore(r io.Reader) (*IgnoreRules, error) {
+	rules := &IgnoreRules{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := trimIgnoreLine(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		var rule ignoreRule
+		if strings.HasPrefix(text, "!") {
+			rule.negate = true
+			text = text[1:]
+		}
+		if strings.HasSuffix(text, "/") {
+			rule.dirOnly = true
+			text = strings.TrimRight(text, "/")
+		}
+		if text == "" {
+			continue
+		}
+
+		// patterns without a slash match at any depth
+		anchored := strings.Contains(text, "/")
+		text = strings.TrimPrefix(text, "/")
+		expr, err := ignorePatternRegexp(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if !anchored {
+			expr = "(?:.*/)?" + expr
+		}
+		if rule.pattern, err = regexp.Compile("^" + expr + "$"); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rules.rules = append(rules.rules, rule)
+	}
+	return rules, scanner.Err()
+}
+
+// Ignored - path relative to the sorted directory, with / separators. like git, a file in an
+// ignored directory cannot be included again
+func (ir *IgnoreRules) Ignored(path string, isDir bool) bool {
+	if ir == nil || len(ir.rules) == 0 {
+		return false
+	}
+	segments := strings.Split(path, "/")
+	for i := 1; i < len(segments); i++ {
+		if ir.match(strings.Join(segments[:i], "/"), true) {
+			return true
+		}
+	}
+	return ir.match(path, isDir)
+}
+
+func (ir *IgnoreRules) match(path string, isDir bool) bool {
+	ignored := false
+	for _, rule := range ir.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.pattern.MatchString(path) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// trimIgnoreLine - trailing spaces are dropped unless escaped, a leading \ keeps # and ! literal
+func trimIgnoreLine(line string) string {
+	line = strings.TrimSuffix(line, "\r")
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	return line
+}
+
+// ignorePatternRegexp - glob to regular expression, / is never matched by * or ?
+func ignorePatternRegexp(pattern string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/") && (i == 0 || pattern[i-1] == '/'):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**") && i+2 == len(pattern) && (i == 0 || pattern[i-1] == '/'):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated [ in %q", pattern)
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^/" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(pattern[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,121 @@
+// Package model - ignore file tests
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIgnoreRules_Ignored(t *testing.T) {
+	patterns := `
+# comment
+*.tmp
+!keep.tmp
+/anchored.txt
+build/
+docs/**/*.md
+report-[0-9].pdf
+\#literal.txt
+\!bang.txt
+trailing.txt   
+escaped\ 
+`
+	rules, err := ParseIgnore(strings.NewReader(patterns))
+	if err != nil {
+		t.Fatalf("ParseIgnore failed: %v", err)
+	}
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"scratch.tmp", false, true},
+		{"sub/scratch.tmp", false, true},
+		{"keep.tmp", false, false},
+		{"anchored.txt", false, true},
+		{"sub/anchored.txt", false, false},
+		{"build", true, true},
+		{"build", false, false},
+		{"build/output.bin", false, true},
+		{"docs/a/b/readme.md", false, true},
+		{"docs/readme.md", false, true},
+		{"readme.md", false, false},
+		{"report-1.pdf", false, true},
+		{"report-a.pdf", false, false},
+		{"#literal.txt", false, true},
+		{"!bang.txt", false, true},
+		{"trailing.txt", false, true},
+		{"escaped ", false, true},
+		{"comment", false, false},
+		{"notes.txt", false, false},
+	}
+	for _, tt := range tests {
+		if got := rules.Ignored(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("Ignored(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestIgnoreRules_Invalid(t *testing.T) {
+	if _, err := ParseIgnore(strings.NewReader("ok.txt\nbad[.txt\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Expected an error for line 2, got %v", err)
+	}
+}
+
+func TestLoadIgnore_GlobalAndLocal(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_ignore_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	originalHome := os.Getenv("HOME")
+	if err := os.Setenv("HOME", tempDir); err != nil {
+		t.Fatalf("Failed to set HOME: %v", err)
+	}
+	defer func() {
+		if err := os.Setenv("HOME", originalHome); err != nil {
+			t.Fatalf("Failed to restore HOME: %v", err)
+		}
+	}()
+
+	var rules *IgnoreRules
+	if rules, err = LoadIgnore(tempDir); err != nil {
+		t.Fatalf("Expected missing ignore files to be no error, got %v", err)
+	}
+	if rules.Ignored("a.pdf", false) {
+		t.Error("Expected nothing to be ignored without ignore files")
+	}
+
+	global, err := GlobalIgnorePath()
+	if err != nil {
+		t.Fatalf("GlobalIgnorePath failed: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(global), 0750); err != nil {
+		t.Fatalf("Failed to create config dir: %v", err)
+	}
+	if err := os.WriteFile(global, []byte("*.pdf\n*.iso\n"), 0644); err != nil {
+		t.Fatalf("Failed to write global ignore file: %v", err)
+	}
+	// the directory's file is read last and includes PDFs again
+	if err := os.WriteFile(filepath.Join(tempDir, IgnoreFileName), []byte("!*.pdf\n"), 0644); err != nil {
+		t.Fatalf("Failed to write ignore file: %v", err)
+	}
+
+	if rules, err = LoadIgnore(tempDir); err != nil {
+		t.Fatalf("LoadIgnore failed: %v", err)
+	}
+	if rules.Ignored("a.pdf", false) {
+		t.Error("Expected the directory's negation to win over the global pattern")
+	}
+	if !rules.Ignored("ubuntu.iso", false) {
+		t.Error("Expected the global pattern to apply")
+	}
+}
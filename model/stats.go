@@ -16,6 +16,13 @@ type Stats struct {
 	TotalFiles           int64
 	ErrorsCount          int64
 	TransparentPNGsMoved int64
+	VersionsMoved        int64
+	ScreenshotsMoved     int64
+	ConflictsSkipped     int64
+	InProgressSkipped    int64
+	Ignored              int64
+	Skipped              int64 // hidden files, junk files, symlinks and special files
+	JunkDeleted          int64
 	UnknownExtensions    int64
 	UnknownExtMap        sync.Map
 }
@@ -40,6 +47,34 @@ func (s *Stats) IncrementTransparentPNGsMoved() {
 	atomic.AddInt64(&s.TransparentPNGsMoved, 1)
 }
 
+func (s *Stats) IncrementVersionsMoved() {
+	atomic.AddInt64(&s.VersionsMoved, 1)
+}
+
+func (s *Stats) IncrementScreenshotsMoved() {
+	atomic.AddInt64(&s.ScreenshotsMoved, 1)
+}
+
+func (s *Stats) IncrementConflictsSkipped() {
+	atomic.AddInt64(&s.ConflictsSkipped, 1)
+}
+
+func (s *Stats) IncrementInProgressSkipped() {
+	atomic.AddInt64(&s.InProgressSkipped, 1)
+}
+
+func (s *Stats) IncrementIgnored() {
+	atomic.AddInt64(&s.Ignored, 1)
+}
+
+func (s *Stats) IncrementSkipped() {
+	atomic.AddInt64(&s.Skipped, 1)
+}
+
+func (s *Stats) IncrementJunkDeleted() {
+	atomic.AddInt64(&s.JunkDeleted, 1)
+}
+
 func (s *Stats) IncrementUnknownExtensions(ext string) {
 	atomic.AddInt64(&s.UnknownExtensions, 1)
 	// Track count for specific extension
@@ -68,6 +103,34 @@ func (s *Stats) GetTransparentPNGsMoved() int64 {
 	return atomic.LoadInt64(&s.TransparentPNGsMoved)
 }
 
+func (s *Stats) GetVersionsMoved() int64 {
+	return atomic.LoadInt64(&s.VersionsMoved)
+}
+
+func (s *Stats) GetScreenshotsMoved() int64 {
+	return atomic.LoadInt64(&s.ScreenshotsMoved)
+}
+
+func (s *Stats) GetConflictsSkipped() int64 {
+	return atomic.LoadInt64(&s.ConflictsSkipped)
+}
+
+func (s *Stats) GetInProgressSkipped() int64 {
+	return atomic.LoadInt64(&s.InProgressSkipped)
+}
+
+func (s *Stats) GetIgnored() int64 {
+	return atomic.LoadInt64(&s.Ignored)
+}
+
+func (s *Stats) GetSkipped() int64 {
+	return atomic.LoadInt64(&s.Skipped)
+}
+
+func (s *Stats) GetJunkDeleted() int64 {
+	return atomic.LoadInt64(&s.JunkDeleted)
+}
+
 func (s *Stats) GetUnknownExtensions() int64 {
 	return atomic.LoadInt64(&s.UnknownExtensions)
 }
@@ -0,0 +1,93 @@
+// Package model - destination templates
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DestinationTemplate - where a file goes and what it is called, both optional
+type DestinationTemplate struct {
+	Folder   string `json:"folder"`   // e.g. "{category}/{yyyy}"
+	FileName string `json:"filename"` // e.g. "{yyyy}-{mm}-{dd}_{name}", the extension is kept
+}
+
+// tokens usable in destination templates
+var TemplateTokens = map[string]string{
+	"category":     "target folder from extension_to_folder",
+	"ext":          "file extension without the dot",
+	"name":         "file name without the extension",
+	"yyyy":         "four digit year (see date_source)",
+	"yy":           "two digit year",
+	"mm":           "two digit month",
+	"dd":           "two digit day",
+	"size_bucket":  "size range like 1-10MB",
+	"hash8":        "first 8 characters of the SHA-256",
+	"exif.model":   "camera model from EXIF",
+	"artist":       "album artist or artist from audio tags",
+	"album":        "album from audio tags",
+	"title":        "title from audio tags",
+	"track":        "two digit track number from audio tags",
+	"doc.title":    "title from PDF/office metadata",
+	"doc.author":   "author from PDF/office metadata",
+	"doc.producer": "producing application or scanner from PDF/office metadata",
+}
+
+var templateTokenPattern = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// TemplateTokensIn - tokens used in a template, in order of appearance
+func TemplateTokensIn(template string) []string {
+	var tokens []string
+	for _, m := range templateTokenPattern.FindAllStringSubmatch(template, -1) {
+		tokens = append(tokens, m[1])
+	}
+	return tokens
+}
+
+// IsEmpty - true if neither folder nor file name are templated
+func (t DestinationTemplate) IsEmpty() bool {
+	return t.Folder == "" && t.FileName == ""
+}
+
+// Validate - unknown tokens, unbalanced braces and separators in file names
+func (t DestinationTemplate) Validate() error {
+	if err := validateTemplate(t.Folder); err != nil {
+		return fmt.Errorf("folder %q: %w", t.Folder, err)
+	}
+	if err := validateTemplate(t.FileName); err != nil {
+		return fmt.Errorf("filename %q: %w", t.FileName, err)
+	}
+	if strings.ContainsAny(t.FileName, `/\`) {
+		return fmt.Errorf("filename %q: must not contain path separators", t.FileName)
+	}
+	if t.FileName != "" && strings.TrimSpace(t.FileName) == "" {
+		return fmt.Errorf("filename %q: is empty", t.FileName)
+	}
+	return nil
+}
+
+func validateTemplate(template string) error {
+	return validateTokens(template, TemplateTokens)
+}
+
+// date tokens, the only ones allowed in extension_to_folder and date_subfolders
+var dateTokens = map[string]string{
+	"yyyy": TemplateTokens["yyyy"],
+	"yy":   TemplateTokens["yy"],
+	"mm":   TemplateTokens["mm"],
+	"dd":   TemplateTokens["dd"],
+}
+
+func validateTokens(template string, allowed map[string]string) error {
+	for _, token := range TemplateTokensIn(template) {
+		if _, ok := allowed[token]; !ok {
+			return fmt.Errorf("unknown token {%s}", token)
+		}
+	}
+	rest := templateTokenPattern.ReplaceAllString(template, "")
+	if strings.ContainsAny(rest, "{}") {
+		return fmt.Errorf("unbalanced braces")
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+// Package service - checkpoints
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/mohamedation/GoSorter/helpers"
+	"github.com/mohamedation/GoSorter/model"
+)
+
+// checkpointInterval - how often progress is written while hashing and moving duplicates
+const checkpointInterval = 5 * time.Second
+
+// checkpointer - the checkpoint of a duplicate detection run. a nil checkpointer
+// (checkpoints off) answers every lookup with nothing and records nothing
+type checkpointer struct {
+	mu    sync.Mutex
+	path  string
+	cp    *model.Checkpoint
+	saved time.Time
+
+	config model.Config
+	logger helpers.Logger
+}
+
+// openCheckpoint - resumes the checkpoint of folderPath when asked to, otherwise starts a new one
+func (fp *FileProcessor) openCheckpoint(folderPath string) *checkpointer {
+	if fp.config.CheckpointFile == "" {
+		return nil
+	}
+	folder, err := filepath.Abs(folderPath)
+	if err != nil {
+		fp.Logger.Log(*fp.config, helpers.Error, fmt.Sprintf("Checkpoints disabled: %v\n", err))
+		return nil
+	}
+
+	c := &checkpointer{path: fp.config.CheckpointFile, saved: time.Now(), config: *fp.config, logger: fp.Logger}
+	if fp.config.Resume {
+		cp, err := model.LoadCheckpoint(c.path)
+		switch {
+		case err != nil:
+			fp.Logger.Log(*fp.config, helpers.Error, fmt.Sprintf("Cannot resume, starting over: %v\n", err))
+		case cp.Folder != folder:
+			fp.Logger.Log(*fp.config, helpers.Error, fmt.Sprintf("Checkpoint is for %s, starting over\n", cp.Folder))
+		default:
+			fp.Logger.Log(*fp.config, helpers.Info, fmt.Sprintf("Resuming run from %s: %d files hashed, %d originals kept\n",
+				cp.Started.Format(time.RFC1123), len(cp.Hashes), len(cp.Originals)))
+			c.cp = cp
+		}
+	}
+	if c.cp == nil {
+		c.cp = model.NewCheckpoint(folder, *fp.config)
+	}
+	return c
+}
+
+// lookup - hashes saved for a file, empty when the file changed since
+func (c *checkpointer) lookup(name string, info os.FileInfo) model.FileHashes {
+	if c == nil {
+		return model.FileHashes{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	h, ok := c.cp.Hashes[name]
+	if !ok || !h.Valid(info.Size(), info.ModTime()) {
+		return model.FileHashes{}
+	}
+	return h
+}
+
+func (c *checkpointer) setPartial(name string, info os.FileInfo, partial string) {
+	c.update(name, info, func(h *model.FileHashes) { h.Partial = partial })
+}
+
+func (c *checkpointer) setFull(name string, info os.FileInfo, full string) {
+	c.update(name, info, func(h *model.FileHashes) { h.Full = full })
+}
+
+func (c *checkpointer) update(name string, info os.FileInfo, set func(*model.FileHashes)) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	h := c.cp.Hashes[name]
+	if !h.Valid(info.Size(), info.ModTime()) {
+		h = model.FileHashes{Size: info.Size(), ModTime: info.ModTime()}
+	}
+	set(&h)
+	c.cp.Hashes[name] = h
+}
+
+// duplicateOf - the original an earlier run kept for this file's content, when it is not this file.
+// the original must still be there unchanged, or this file could be the last copy left
+func (c *checkpointer) duplicateOf(path string, info os.FileInfo) string {
+	if c == nil {
+		return ""
+	}
+	h := c.lookup(filepath.Base(path), info)
+	if h.Full == "" {
+		return ""
+	}
+	c.mu.Lock()
+	original, ok := c.cp.Originals[h.Full]
+	recorded := c.cp.Hashes[filepath.Base(original)]
+	c.mu.Unlock()
+	if !ok || original == path {
+		return ""
+	}
+
+	originalInfo, err := os.Stat(original)
+	if err != nil || !originalInfo.Mode().IsRegular() || recorded.Full != h.Full || !recorded.Valid(originalInfo.Size(), originalInfo.ModTime()) {
+		c.logger.Log(c.config, helpers.Debug, fmt.Sprintf("Original %s of %s is gone or changed, comparing again\n", original, path))
+		return ""
+	}
+	return original
+}
+
+// setOriginal - recorded before the duplicates of a group are moved
+func (c *checkpointer) setOriginal(hash, path string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cp.Originals[hash] = path
+}
+
+// maybeSave - saves when the last save is older than checkpointInterval
+func (c *checkpointer) maybeSave() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	due := time.Since(c.saved) >= checkpointInterval
+	c.mu.Unlock()
+	if due {
+		c.save()
+	}
+}
+
+func (c *checkpointer) save() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.cp.Save(c.path); err != nil {
+		c.logger.Log(c.config, helpers.Error, fmt.Sprintf("Failed to save checkpoint %s: %v\n", c.path, err))
+	}
+	c.saved = time.Now()
+}
+
+// finish - a completed run needs no checkpoint, a failed or interrupted one keeps its progress
+func (c *checkpointer) finish(err error) {
+	if c == nil {
+		return
+	}
+	if err != nil {
+		c.save()
+		return
+	}
+	if rmErr := os.Remove(c.path); rmErr != nil && !os.IsNotExist(rmErr) {
+		c.logger.Log(c.config, helpers.Error, fmt.Sprintf("Failed to remove checkpoint %s: %v\n", c.path, rmErr))
+	}
+}
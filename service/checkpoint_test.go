@@ -0,0 +1,182 @@
+// Package service - checkpoint tests
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mohamedation/GoSorter/helpers"
+	"github.com/mohamedation/GoSorter/model"
+)
+
+func TestFileProcessor_ResumeReusesHashes(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_resume")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+	sortDir := filepath.Join(tempDir, "sort")
+	if err := os.MkdirAll(sortDir, 0750); err != nil {
+		t.Fatalf("Failed to create sort dir: %v", err)
+	}
+
+	// different content of the same size, but the checkpoint of the interrupted run says they are the same
+	checkpoint := model.NewCheckpoint(sortDir, model.Config{MoveDuplicates: true})
+	for _, name := range []string{"a.pdf", "bb.pdf"} {
+		path := filepath.Join(sortDir, name)
+		if err := os.WriteFile(path, []byte(name[:1]+" content"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+	for _, name := range []string{"a.pdf", "bb.pdf"} {
+		info, err := os.Stat(filepath.Join(sortDir, name))
+		if err != nil {
+			t.Fatalf("Failed to stat test file: %v", err)
+		}
+		checkpoint.Hashes[name] = model.FileHashes{Size: info.Size(), ModTime: info.ModTime(), Partial: "partial", Full: "full"}
+	}
+	checkpointFile := filepath.Join(tempDir, "checkpoint.json")
+	if err := checkpoint.Save(checkpointFile); err != nil {
+		t.Fatalf("Failed to save checkpoint: %v", err)
+	}
+
+	config := &model.Config{Silent: true, MoveDuplicates: true, CheckpointFile: checkpointFile, Resume: true}
+	stats := &model.Stats{StartTime: time.Now()}
+	processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+
+	if err := processor.ProcessDirectory(context.Background(), sortDir); err != nil {
+		t.Fatalf("ProcessDirectory failed: %v", err)
+	}
+
+	if !helpers.FileExists(filepath.Join(sortDir, "Duplicates", "bb_duplicate_of_a.pdf")) {
+		t.Error("Expected the saved hashes to be used instead of hashing again")
+	}
+	if !helpers.FileExists(filepath.Join(sortDir, "PDFs", "a.pdf")) {
+		t.Error("Expected a.pdf to be sorted")
+	}
+	if _, err := os.Stat(checkpointFile); !os.IsNotExist(err) {
+		t.Errorf("Expected the checkpoint to be removed after a completed run, got %v", err)
+	}
+}
+
+func TestFileProcessor_ResumeAfterInterrupt(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_resume_interrupt")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+	sortDir := filepath.Join(tempDir, "sort")
+	if err := os.MkdirAll(sortDir, 0750); err != nil {
+		t.Fatalf("Failed to create sort dir: %v", err)
+	}
+
+	// 20 contents, each in an original and two copies
+	const contents = 20
+	for i := range contents {
+		for _, suffix := range []string{"", "_copy", "_copy2"} {
+			name := fmt.Sprintf("report%02d%s.pdf", i, suffix)
+			if err := os.WriteFile(filepath.Join(sortDir, name), []byte(fmt.Sprintf("content %d", i)), 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+		}
+	}
+	checkpointFile := filepath.Join(tempDir, "checkpoint.json")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	config := &model.Config{MoveDuplicates: true, CheckpointFile: checkpointFile}
+	stats := &model.Stats{StartTime: time.Now()}
+	processor := NewFileProcessor(config, stats, &cancelLogger{cancel: cancel})
+	if err := processor.ProcessDirectory(ctx, sortDir); err != context.Canceled {
+		t.Fatalf("Expected context.Canceled, got %v", err)
+	}
+
+	saved, err := model.LoadCheckpoint(checkpointFile)
+	if err != nil {
+		t.Fatalf("Expected a checkpoint after the interruption: %v", err)
+	}
+	if len(saved.Originals) == 0 || len(saved.Hashes) == 0 {
+		t.Fatalf("Expected hashes and originals in the checkpoint, got %d and %d", len(saved.Hashes), len(saved.Originals))
+	}
+
+	resumed := &model.Config{Silent: true, MoveDuplicates: true, CheckpointFile: checkpointFile, Resume: true}
+	processor = NewFileProcessor(resumed, &model.Stats{StartTime: time.Now()}, &helpers.CLILogger{})
+	if err := processor.ProcessDirectory(context.Background(), sortDir); err != nil {
+		t.Fatalf("Resumed ProcessDirectory failed: %v", err)
+	}
+
+	for i := range contents {
+		if !helpers.FileExists(filepath.Join(sortDir, "PDFs", fmt.Sprintf("report%02d.pdf", i))) {
+			t.Errorf("Expected report%02d.pdf to be sorted", i)
+		}
+		for _, suffix := range []string{"_copy", "_copy2"} {
+			name := fmt.Sprintf("report%02d%s_duplicate_of_report%02d.pdf", i, suffix, i)
+			if !helpers.FileExists(filepath.Join(sortDir, "Duplicates", name)) {
+				t.Errorf("Expected duplicate %s", name)
+			}
+		}
+	}
+	if _, err := os.Stat(checkpointFile); !os.IsNotExist(err) {
+		t.Errorf("Expected the checkpoint to be removed after the resumed run, got %v", err)
+	}
+}
+
+func TestFileProcessor_ResumeOriginalGone(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_resume_gone")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+	sortDir := filepath.Join(tempDir, "sort")
+	if err := os.MkdirAll(sortDir, 0750); err != nil {
+		t.Fatalf("Failed to create sort dir: %v", err)
+	}
+
+	// the interrupted run kept a.pdf and b.pdf was its copy, a.pdf was deleted since
+	path := filepath.Join(sortDir, "b.pdf")
+	if err := os.WriteFile(path, []byte("last copy"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Failed to stat test file: %v", err)
+	}
+	checkpoint := model.NewCheckpoint(sortDir, model.Config{MoveDuplicates: true})
+	checkpoint.Hashes["a.pdf"] = model.FileHashes{Size: info.Size(), ModTime: info.ModTime(), Partial: "partial", Full: "full"}
+	checkpoint.Hashes["b.pdf"] = model.FileHashes{Size: info.Size(), ModTime: info.ModTime(), Partial: "partial", Full: "full"}
+	checkpoint.Originals["full"] = filepath.Join(sortDir, "a.pdf")
+	checkpointFile := filepath.Join(tempDir, "checkpoint.json")
+	if err := checkpoint.Save(checkpointFile); err != nil {
+		t.Fatalf("Failed to save checkpoint: %v", err)
+	}
+
+	config := &model.Config{Silent: true, MoveDuplicates: true, CheckpointFile: checkpointFile, Resume: true}
+	stats := &model.Stats{StartTime: time.Now()}
+	processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+	if err := processor.ProcessDirectory(context.Background(), sortDir); err != nil {
+		t.Fatalf("ProcessDirectory failed: %v", err)
+	}
+
+	if !helpers.FileExists(filepath.Join(sortDir, "PDFs", "b.pdf")) {
+		t.Error("Expected the last copy to be sorted, not moved to Duplicates")
+	}
+	if got := stats.GetDuplicatesMoved(); got != 0 {
+		t.Errorf("Expected no duplicates, got %d", got)
+	}
+}
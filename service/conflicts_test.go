@@ -0,0 +1,377 @@
+// Package service - conflict policy tests
+package service
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mohamedation/GoSorter/helpers"
+	"github.com/mohamedation/GoSorter/model"
+)
+
+func TestFileProcessor_ConflictPolicies(t *testing.T) {
+	older := time.Now().Add(-2 * time.Hour)
+	newer := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name        string
+		policy      string // -C
+		perExt      string // conflict_policies[".pdf"]
+		pattern     string
+		incoming    string
+		existing    string
+		incomingOld bool // incoming file modified before the existing one
+		wantTarget  string
+		wantFiles   map[string]string // path relative to the sorted directory -> content
+		wantSkipped int64
+		wantDups    int64
+	}{
+		{
+			name:      "rename different",
+			incoming:  "new report",
+			existing:  "old report",
+			wantFiles: map[string]string{"PDFs/report.pdf": "old report", "PDFs/report(1).pdf": "new report"},
+		},
+		{
+			name:      "rename identical replaces",
+			incoming:  "report",
+			existing:  "report",
+			wantFiles: map[string]string{"PDFs/report.pdf": "report"},
+		},
+		{
+			name:      "rename pattern",
+			pattern:   "{name} ({n}){ext}",
+			incoming:  "new report",
+			existing:  "old report",
+			wantFiles: map[string]string{"PDFs/report.pdf": "old report", "PDFs/report (1).pdf": "new report"},
+		},
+		{
+			name:        "skip",
+			policy:      model.ConflictSkip,
+			incoming:    "new report",
+			existing:    "old report",
+			wantFiles:   map[string]string{"PDFs/report.pdf": "old report", "report.pdf": "new report"},
+			wantSkipped: 1,
+		},
+		{
+			name:      "overwrite if newer",
+			policy:    model.ConflictOverwriteIfNewer,
+			incoming:  "new report",
+			existing:  "old report",
+			wantFiles: map[string]string{"PDFs/report.pdf": "new report"},
+		},
+		{
+			name:        "overwrite if newer keeps newer existing",
+			policy:      model.ConflictOverwriteIfNewer,
+			incoming:    "new report",
+			existing:    "old report",
+			incomingOld: true,
+			wantFiles:   map[string]string{"PDFs/report.pdf": "old report", "report.pdf": "new report"},
+			wantSkipped: 1,
+		},
+		{
+			name:      "keep larger",
+			policy:    model.ConflictKeepLarger,
+			incoming:  "longer report",
+			existing:  "report",
+			wantFiles: map[string]string{"PDFs/report.pdf": "longer report"},
+		},
+		{
+			name:        "keep larger keeps larger existing",
+			policy:      model.ConflictKeepLarger,
+			incoming:    "report",
+			existing:    "longer report",
+			wantFiles:   map[string]string{"PDFs/report.pdf": "longer report", "report.pdf": "report"},
+			wantSkipped: 1,
+		},
+		{
+			name:      "duplicates identical",
+			policy:    model.ConflictDuplicates,
+			incoming:  "report",
+			existing:  "report",
+			wantFiles: map[string]string{"PDFs/report.pdf": "report", "Duplicates/report_duplicate_of_report.pdf": "report"},
+			wantDups:  1,
+		},
+		{
+			name:      "duplicates different",
+			policy:    model.ConflictDuplicates,
+			incoming:  "new report",
+			existing:  "old report",
+			wantFiles: map[string]string{"PDFs/report.pdf": "old report", "PDFs/report(1).pdf": "new report"},
+		},
+		{
+			name:        "per extension beats flag",
+			policy:      model.ConflictKeepLarger,
+			perExt:      model.ConflictSkip,
+			incoming:    "longer report",
+			existing:    "report",
+			wantFiles:   map[string]string{"PDFs/report.pdf": "report", "report.pdf": "longer report"},
+			wantSkipped: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir, err := os.MkdirTemp("", "gosorter_test_conflicts")
+			if err != nil {
+				t.Fatalf("Failed to create temp dir: %v", err)
+			}
+			defer func() {
+				if err := os.RemoveAll(tempDir); err != nil {
+					t.Fatalf("Failed to remove temp dir: %v", err)
+				}
+			}()
+
+			incomingTime, existingTime := newer, older
+			if tt.incomingOld {
+				incomingTime, existingTime = older, newer
+			}
+			writeConflictFile(t, filepath.Join(tempDir, "report.pdf"), tt.incoming, incomingTime)
+			writeConflictFile(t, filepath.Join(tempDir, "PDFs", "report.pdf"), tt.existing, existingTime)
+
+			config := &model.Config{Silent: true, ConflictPolicy: tt.policy}
+			stats := &model.Stats{StartTime: time.Now()}
+			processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+			if tt.perExt != "" {
+				processor.extConfig.ConflictPolicies = map[string]string{".pdf": tt.perExt}
+			}
+			if tt.pattern != "" {
+				processor.extConfig.ConflictRenamePattern = tt.pattern
+			}
+
+			if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			for rel, want := range tt.wantFiles {
+				content, err := os.ReadFile(filepath.Join(tempDir, filepath.FromSlash(rel)))
+				if err != nil {
+					t.Errorf("Expected %s: %v", rel, err)
+					continue
+				}
+				if string(content) != want {
+					t.Errorf("Expected %s to contain %q, got %q", rel, want, content)
+				}
+			}
+			entries, err := os.ReadDir(filepath.Join(tempDir, "PDFs"))
+			if err != nil {
+				t.Fatalf("Failed to read PDFs: %v", err)
+			}
+			pdfs := 0
+			for rel := range tt.wantFiles {
+				if strings.HasPrefix(rel, "PDFs/") {
+					pdfs++
+				}
+			}
+			if len(entries) != pdfs {
+				t.Errorf("Expected %d files in PDFs, got %d", pdfs, len(entries))
+			}
+			if got := stats.GetConflictsSkipped(); got != tt.wantSkipped {
+				t.Errorf("Expected %d skipped conflicts, got %d", tt.wantSkipped, got)
+			}
+			if got := stats.GetDuplicatesMoved(); got != tt.wantDups {
+				t.Errorf("Expected %d duplicates, got %d", tt.wantDups, got)
+			}
+			if got := stats.GetErrorsCount(); got != 0 {
+				t.Errorf("Expected no errors, got %d", got)
+			}
+		})
+	}
+}
+
+func TestFileProcessor_ConflictPrompt(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_conflict_prompt")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	for _, name := range []string{"a.pdf", "b.pdf", "c.pdf"} {
+		writeConflictFile(t, filepath.Join(tempDir, name), "new "+name, time.Now())
+		writeConflictFile(t, filepath.Join(tempDir, "PDFs", name), "old "+name, time.Now())
+	}
+
+	config := &model.Config{Silent: true, ConflictPolicy: model.ConflictPrompt}
+	stats := &model.Stats{StartTime: time.Now()}
+	processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+	// an unknown answer asks again, then the first file is skipped and all others overwritten
+	var out bytes.Buffer
+	processor.prompter = newConflictPrompter(strings.NewReader("x\ns\nO\n"), &out)
+
+	if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+		t.Fatalf("ProcessDirectory failed: %v", err)
+	}
+
+	if got := strings.Count(out.String(), "already exists"); got != 3 {
+		t.Errorf("Expected 3 questions, got %d:\n%s", got, out.String())
+	}
+	if got := stats.GetConflictsSkipped(); got != 1 {
+		t.Errorf("Expected 1 skipped conflict, got %d", got)
+	}
+	if got := stats.GetFilesMoved(); got != 2 {
+		t.Errorf("Expected 2 files moved, got %d", got)
+	}
+	overwritten := 0
+	for _, name := range []string{"a.pdf", "b.pdf", "c.pdf"} {
+		content, err := os.ReadFile(filepath.Join(tempDir, "PDFs", name))
+		if err != nil {
+			t.Fatalf("Expected PDFs/%s: %v", name, err)
+		}
+		if string(content) == "new "+name {
+			overwritten++
+		}
+	}
+	if overwritten != 2 {
+		t.Errorf("Expected 2 overwritten files, got %d", overwritten)
+	}
+}
+
+func TestFileProcessor_ConflictLargeFilesNotIdentical(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_conflict_large")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	// same size, different content, both above the hash limit
+	incoming := bytes.Repeat([]byte("a"), 2*1024*1024)
+	existing := bytes.Repeat([]byte("b"), 2*1024*1024)
+	writeConflictFile(t, filepath.Join(tempDir, "video.mp4"), string(incoming), time.Now())
+	writeConflictFile(t, filepath.Join(tempDir, "Videos", "video.mp4"), string(existing), time.Now())
+
+	config := &model.Config{Silent: true, MaxHashFileSizeMB: 1}
+	stats := &model.Stats{StartTime: time.Now()}
+	processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+	if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+		t.Fatalf("ProcessDirectory failed: %v", err)
+	}
+
+	if !helpers.FileExists(filepath.Join(tempDir, "Videos", "video(1).mp4")) {
+		t.Error("Expected the unhashed file to be renamed instead of replacing the existing one")
+	}
+	content, err := os.ReadFile(filepath.Join(tempDir, "Videos", "video.mp4"))
+	if err != nil || !bytes.Equal(content, existing) {
+		t.Errorf("Expected the existing file to be kept, got err %v", err)
+	}
+}
+
+func TestFileProcessor_ConflictTrash(t *testing.T) {
+	if !helpers.TrashSupported {
+		t.Skip("the freedesktop.org Trash is not used on " + runtime.GOOS)
+	}
+	tempDir, err := os.MkdirTemp("", "gosorter_test_conflict_trash")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	originalDataHome := os.Getenv("XDG_DATA_HOME")
+	if err := os.Setenv("XDG_DATA_HOME", filepath.Join(tempDir, "data")); err != nil {
+		t.Fatalf("Failed to set XDG_DATA_HOME: %v", err)
+	}
+	defer func() {
+		if err := os.Setenv("XDG_DATA_HOME", originalDataHome); err != nil {
+			t.Fatalf("Failed to restore XDG_DATA_HOME: %v", err)
+		}
+	}()
+	trash := filepath.Join(tempDir, "data", "Trash")
+
+	sortDir := filepath.Join(tempDir, "my files")
+	for run, content := range []string{"first", "second"} {
+		writeConflictFile(t, filepath.Join(sortDir, "report.pdf"), content, time.Now())
+		writeConflictFile(t, filepath.Join(sortDir, "PDFs", "report.pdf"), content, time.Now())
+
+		config := &model.Config{Silent: true, UseTrash: true}
+		stats := &model.Stats{StartTime: time.Now()}
+		processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+		if err := processor.ProcessDirectory(context.Background(), sortDir); err != nil {
+			t.Fatalf("ProcessDirectory failed: %v", err)
+		}
+		if got := stats.GetErrorsCount(); got != 0 {
+			t.Fatalf("Run %d: expected no errors, got %d", run+1, got)
+		}
+	}
+
+	for _, name := range []string{"report.pdf", "report.2.pdf"} {
+		if !helpers.FileExists(filepath.Join(trash, "files", name)) {
+			t.Errorf("Expected %s in the Trash", name)
+		}
+		info, err := os.ReadFile(filepath.Join(trash, "info", name+".trashinfo"))
+		if err != nil {
+			t.Errorf("Expected trashinfo for %s: %v", name, err)
+			continue
+		}
+		wantPath := "Path=" + filepath.ToSlash(strings.ReplaceAll(filepath.Join(sortDir, "PDFs", "report.pdf"), " ", "%20"))
+		if !strings.HasPrefix(string(info), "[Trash Info]\n") || !strings.Contains(string(info), wantPath+"\n") || !strings.Contains(string(info), "DeletionDate=") {
+			t.Errorf("Unexpected trashinfo for %s:\n%s", name, info)
+		}
+	}
+	if content, err := os.ReadFile(filepath.Join(sortDir, "PDFs", "report.pdf")); err != nil || string(content) != "second" {
+		t.Errorf("Expected the last incoming file at the destination, got %q (%v)", content, err)
+	}
+}
+
+func TestFileProcessor_DestinationIsSource(t *testing.T) {
+	for _, policy := range []string{model.ConflictRename, model.ConflictDuplicates, model.ConflictOverwrite, model.ConflictKeepLarger} {
+		t.Run(policy, func(t *testing.T) {
+			tempDir, err := os.MkdirTemp("", "gosorter_test_conflict_self")
+			if err != nil {
+				t.Fatalf("Failed to create temp dir: %v", err)
+			}
+			defer func() {
+				if err := os.RemoveAll(tempDir); err != nil {
+					t.Fatalf("Failed to remove temp dir: %v", err)
+				}
+			}()
+			writeConflictFile(t, filepath.Join(tempDir, "report.pdf"), "report", time.Now().Add(-time.Hour))
+
+			config := &model.Config{Silent: true, ConflictPolicy: policy}
+			stats := &model.Stats{StartTime: time.Now()}
+			processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+			// the destination of report.pdf is report.pdf itself
+			processor.extConfig.ExtensionToFolder[".pdf"] = tempDir
+			processor.extConfig.AllowOutsideFolders = true
+			if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			if content, err := os.ReadFile(filepath.Join(tempDir, "report.pdf")); err != nil || string(content) != "report" {
+				t.Errorf("Expected report.pdf to stay untouched, got %q (%v)", content, err)
+			}
+			if got := stats.GetErrorsCount(); got != 0 {
+				t.Errorf("Expected no errors, got %d", got)
+			}
+		})
+	}
+}
+
+func writeConflictFile(t *testing.T, path, content string, modTime time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("Failed to set file time: %v", err)
+	}
+}
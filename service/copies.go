@@ -0,0 +1,171 @@
+// Package service - copy consolidation
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/mohamedation/GoSorter/helpers"
+)
+
+type copyMember struct {
+	entry   os.DirEntry
+	path    string
+	modTime time.Time
+}
+
+// consolidateCopies - groups "name (1).ext", "name - Copy.ext" etc. with their original.
+// identical members are moved to Duplicates, differing ones keep the newest in place
+// and move the older versions to the Versions folder. numbered names only form a family
+// with their original or an explicit copy, and "name copy.ext" is never an older version.
+// returns the entries left to sort.
+func (fp *FileProcessor) consolidateCopies(ctx context.Context, folderPath string, entries []os.DirEntry) ([]os.DirEntry, error) {
+	fp.Logger.Log(*fp.config, helpers.Debug, "[DEBUG] Grouping files into copy families\n")
+	families := make(map[string][]os.DirEntry)
+	kinds := make(map[string]helpers.VariantKind)
+	// GoSorter's own conflict renames are copies too
+	renamed := helpers.RenamedCopyPattern(fp.extConfig.ConflictRenamePattern)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		base, kind := helpers.ParseVariant(entry.Name(), renamed)
+		families[base] = append(families[base], entry)
+		kinds[entry.Name()] = kind
+	}
+
+	// "Song (1).mp3" and "Song (2).mp3" alone are more likely two songs than two copies,
+	// a family needs its original or a name that can only be a copy
+	bases := make([]string, 0, len(families))
+	for base, family := range families {
+		if len(family) < 2 {
+			continue
+		}
+		for _, entry := range family {
+			if entry.Name() == base || kinds[entry.Name()] == helpers.ExplicitVariant {
+				bases = append(bases, base)
+				break
+			}
+		}
+	}
+	sort.Strings(bases)
+
+	maxBytes := fp.config.MaxHashFileSizeMB
+	if maxBytes <= 0 {
+		maxBytes = 1024
+	}
+	maxBytes = maxBytes * 1024 * 1024
+
+	consumed := make(map[string]bool)
+	for _, base := range bases {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		family := families[base]
+		fp.Logger.Log(*fp.config, helpers.Debug, fmt.Sprintf("[DEBUG] Found %d copies of %s\n", len(family), base))
+
+		hashGroups := make(map[string][]copyMember)
+		var hashOrder []string
+		for _, entry := range family {
+			filePath := filepath.Join(folderPath, entry.Name())
+			info, err := entry.Info()
+			if err != nil {
+				fp.stats.IncrementErrors()
+				fp.Logger.Log(*fp.config, helpers.Error, fmt.Sprintf("Failed to stat file %s: %v\n", filePath, err))
+				continue
+			}
+			hash, err := helpers.HashFile(ctx, filePath, maxBytes, *fp.config, fp.Logger)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, ctx.Err()
+				}
+				fp.stats.IncrementErrors()
+				fp.Logger.Log(*fp.config, helpers.Error, fmt.Sprintf("Failed to hash file %s: %v\n", filePath, err))
+				continue
+			}
+			if hash == "" {
+				// too large to compare, leave it for normal sorting
+				continue
+			}
+			if _, ok := hashGroups[hash]; !ok {
+				hashOrder = append(hashOrder, hash)
+			}
+			hashGroups[hash] = append(hashGroups[hash], copyMember{entry: entry, path: filePath, modTime: info.ModTime()})
+		}
+
+		// identical content: keep one, the rest are duplicates
+		var versions []copyMember
+		for _, hash := range hashOrder {
+			group := hashGroups[hash]
+			keeper := pickCopyKeeper(base, group)
+			for _, member := range group {
+				if member.path == keeper.path {
+					continue
+				}
+				if helpers.MoveDuplicateFile(ctx, folderPath, member.entry.Name(), keeper.path, fp.extConfig.DuplicatesFolder, *fp.config, fp.Logger) == "" {
+					continue
+				}
+				fp.stats.IncrementTotalFiles()
+				fp.stats.IncrementDuplicatesMoved()
+				consumed[member.entry.Name()] = true
+			}
+			// "Hard copy.pdf" next to "Hard.pdf" may be a different document, it is only a copy when identical
+			if kinds[keeper.entry.Name()] != helpers.WeakVariant {
+				versions = append(versions, keeper)
+			}
+		}
+
+		if len(versions) < 2 {
+			continue
+		}
+
+		// differing content: newest stays, older versions are set aside
+		newest := versions[0]
+		for _, v := range versions[1:] {
+			if v.modTime.After(newest.modTime) {
+				newest = v
+			}
+		}
+		for _, v := range versions {
+			if v.path == newest.path {
+				continue
+			}
+			fp.Logger.Log(*fp.config, helpers.Debug, fmt.Sprintf("Older version of %s: %s\n", newest.entry.Name(), v.entry.Name()))
+			if helpers.MoveFileToTargetFolder(ctx, folderPath, v.entry.Name(), fp.extConfig.VersionsFolder, *fp.config, fp.Logger) == "" {
+				continue
+			}
+			fp.stats.IncrementTotalFiles()
+			fp.stats.IncrementVersionsMoved()
+			consumed[v.entry.Name()] = true
+		}
+	}
+
+	remaining := make([]os.DirEntry, 0, len(entries))
+	for _, entry := range entries {
+		if !consumed[entry.Name()] {
+			remaining = append(remaining, entry)
+		}
+	}
+	return remaining, nil
+}
+
+// prefer the un-suffixed original, otherwise the newest copy
+func pickCopyKeeper(base string, group []copyMember) copyMember {
+	keeper := group[0]
+	for _, member := range group {
+		if member.entry.Name() == base {
+			return member
+		}
+		if member.modTime.After(keeper.modTime) {
+			keeper = member
+		}
+	}
+	return keeper
+}
@@ -0,0 +1,198 @@
+// Package service - copy consolidation tests
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mohamedation/GoSorter/helpers"
+	"github.com/mohamedation/GoSorter/model"
+)
+
+func TestFileProcessor_ConsolidateCopies(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_copies")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	now := time.Now()
+	testFiles := []struct {
+		name    string
+		content string
+		age     time.Duration
+	}{
+		{"report.pdf", "v1", 3 * time.Hour},
+		{"report (1).pdf", "v1", 2 * time.Hour},
+		{"report - Copy.pdf", "v2", 1 * time.Hour},
+		{"notes copy.txt", "only copy", 0},
+	}
+	for _, f := range testFiles {
+		filePath := filepath.Join(tempDir, f.name)
+		if err := os.WriteFile(filePath, []byte(f.content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", f.name, err)
+		}
+		mtime := now.Add(-f.age)
+		if err := os.Chtimes(filePath, mtime, mtime); err != nil {
+			t.Fatalf("Failed to set mtime on %s: %v", f.name, err)
+		}
+	}
+
+	config := &model.Config{
+		ConsolidateCopies: true,
+		DuplicatesOnly:    true,
+		MoveDuplicates:    true,
+		Silent:            true,
+	}
+	stats := &model.Stats{StartTime: time.Now()}
+
+	processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+	if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+		t.Fatalf("ProcessDirectory with copy consolidation failed: %v", err)
+	}
+
+	if stats.GetDuplicatesMoved() != 1 {
+		t.Errorf("Expected 1 duplicate moved, got %d", stats.GetDuplicatesMoved())
+	}
+	if stats.GetVersionsMoved() != 1 {
+		t.Errorf("Expected 1 older version moved, got %d", stats.GetVersionsMoved())
+	}
+
+	if !helpers.FileExists(filepath.Join(tempDir, "report - Copy.pdf")) {
+		t.Error("Expected newest version to stay in place")
+	}
+	if !helpers.FileExists(filepath.Join(tempDir, "Versions", "report.pdf")) {
+		t.Error("Expected older version to be moved to Versions")
+	}
+	if !helpers.FileExists(filepath.Join(tempDir, "Duplicates", "report (1)_duplicate_of_report.pdf")) {
+		t.Error("Expected identical copy to be moved to Duplicates")
+	}
+	if !helpers.FileExists(filepath.Join(tempDir, "notes copy.txt")) {
+		t.Error("Expected a lone copy without its original to be left alone")
+	}
+}
+
+func TestFileProcessor_ConsolidateRenamedCopies(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_renamed_copies")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	// names an earlier run gave with conflict_rename_pattern "{name} [{n}]{ext}"
+	now := time.Now()
+	testFiles := []struct {
+		name    string
+		content string
+		age     time.Duration
+	}{
+		{"report.pdf", "v1", 2 * time.Hour},
+		{"report [1].pdf", "v2", time.Hour},
+	}
+	for _, f := range testFiles {
+		filePath := filepath.Join(tempDir, f.name)
+		if err := os.WriteFile(filePath, []byte(f.content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", f.name, err)
+		}
+		mtime := now.Add(-f.age)
+		if err := os.Chtimes(filePath, mtime, mtime); err != nil {
+			t.Fatalf("Failed to set mtime on %s: %v", f.name, err)
+		}
+	}
+
+	config := &model.Config{
+		ConsolidateCopies: true,
+		DuplicatesOnly:    true,
+		MoveDuplicates:    true,
+		Silent:            true,
+	}
+	stats := &model.Stats{StartTime: time.Now()}
+	processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+	processor.extConfig.ConflictRenamePattern = "{name} [{n}]{ext}"
+	if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+		t.Fatalf("ProcessDirectory with copy consolidation failed: %v", err)
+	}
+
+	if !helpers.FileExists(filepath.Join(tempDir, "Versions", "report.pdf")) {
+		t.Error("Expected the older version to be moved to Versions")
+	}
+	if !helpers.FileExists(filepath.Join(tempDir, "report [1].pdf")) {
+		t.Error("Expected the renamed newest version to stay in place")
+	}
+}
+
+func TestFileProcessor_ConsolidateCopiesFamilies(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_copy_families")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	now := time.Now()
+	testFiles := []struct {
+		name    string
+		content string
+		age     time.Duration
+	}{
+		{"Tax return (2023).pdf", "2023", 2 * time.Hour}, // years, not copies
+		{"Tax return (2024).pdf", "2024", time.Hour},
+		{"Hard.pdf", "hard", 2 * time.Hour}, // "Hard copy" is its own document
+		{"Hard copy.pdf", "hard copy", time.Hour},
+		{"Song (1).mp3", "one", 2 * time.Hour}, // no original, no explicit copy
+		{"Song (2).mp3", "two", time.Hour},
+		{"Memo.pdf", "memo", 2 * time.Hour}, // identical "copy" is a duplicate
+		{"Memo copy.pdf", "memo", time.Hour},
+	}
+	for _, f := range testFiles {
+		filePath := filepath.Join(tempDir, f.name)
+		if err := os.WriteFile(filePath, []byte(f.content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", f.name, err)
+		}
+		mtime := now.Add(-f.age)
+		if err := os.Chtimes(filePath, mtime, mtime); err != nil {
+			t.Fatalf("Failed to set mtime on %s: %v", f.name, err)
+		}
+	}
+
+	config := &model.Config{
+		ConsolidateCopies: true,
+		DuplicatesOnly:    true,
+		MoveDuplicates:    true,
+		Silent:            true,
+	}
+	stats := &model.Stats{StartTime: time.Now()}
+	processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+	if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+		t.Fatalf("ProcessDirectory with copy consolidation failed: %v", err)
+	}
+
+	if got := stats.GetVersionsMoved(); got != 0 {
+		t.Errorf("Expected no older versions, got %d", got)
+	}
+	for _, f := range testFiles {
+		if f.name == "Memo copy.pdf" {
+			continue
+		}
+		if !helpers.FileExists(filepath.Join(tempDir, f.name)) {
+			t.Errorf("Expected %s to stay in place", f.name)
+		}
+	}
+	if !helpers.FileExists(filepath.Join(tempDir, "Duplicates", "Memo copy_duplicate_of_Memo.pdf")) {
+		t.Error("Expected the identical copy to be moved to Duplicates")
+	}
+}
@@ -0,0 +1,106 @@
+// Package service - cross-filesystem move tests
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mohamedation/GoSorter/helpers"
+	"github.com/mohamedation/GoSorter/model"
+)
+
+// otherFilesystem - a directory on another filesystem than dir, skips the test if there is none
+func otherFilesystem(t *testing.T, dir string) string {
+	t.Helper()
+	other, err := os.MkdirTemp("/dev/shm", "gosorter_test_other_fs")
+	if err != nil {
+		t.Skipf("No second filesystem available: %v", err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(other) })
+
+	probe := filepath.Join(dir, "probe")
+	if err := os.WriteFile(probe, nil, 0644); err != nil {
+		t.Fatalf("Failed to create probe file: %v", err)
+	}
+	defer func() { _ = os.Remove(probe) }()
+	if err := os.Rename(probe, filepath.Join(other, "probe")); err == nil {
+		t.Skip("/dev/shm is on the same filesystem")
+	}
+	return other
+}
+
+func TestFileProcessor_CrossFilesystemMove(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_cross_fs")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+	other := otherFilesystem(t, tempDir)
+
+	// PDFs lives on the other filesystem
+	if err := os.Symlink(other, filepath.Join(tempDir, "PDFs")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+	src := filepath.Join(tempDir, "report.pdf")
+	if err := os.WriteFile(src, []byte("quarterly report"), 0600); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := os.Chmod(src, 0640); err != nil {
+		t.Fatalf("Failed to chmod test file: %v", err)
+	}
+	modTime := time.Date(2020, 3, 14, 15, 9, 26, 0, time.UTC)
+	if err := os.Chtimes(src, modTime, modTime); err != nil {
+		t.Fatalf("Failed to set test file times: %v", err)
+	}
+
+	config := &model.Config{Silent: true, VerifyCopies: true}
+	stats := &model.Stats{StartTime: time.Now()}
+	processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+
+	if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+		t.Fatalf("ProcessDirectory failed: %v", err)
+	}
+
+	dst := filepath.Join(other, "report.pdf")
+	content, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatalf("Expected report.pdf on the other filesystem: %v", err)
+	}
+	if string(content) != "quarterly report" {
+		t.Errorf("Expected the content to be copied, got %q", content)
+	}
+	info, err := os.Stat(dst)
+	if err != nil {
+		t.Fatalf("Failed to stat moved file: %v", err)
+	}
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("Expected permissions 0640 to be kept, got %v", info.Mode().Perm())
+	}
+	if !info.ModTime().Equal(modTime) {
+		t.Errorf("Expected modification time %v to be kept, got %v", modTime, info.ModTime())
+	}
+	if helpers.FileExists(src) {
+		t.Error("Expected the original to be removed after the copy")
+	}
+
+	entries, err := os.ReadDir(other)
+	if err != nil {
+		t.Fatalf("Failed to list other filesystem: %v", err)
+	}
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), ".gosorter-") {
+			t.Errorf("Expected no temporary files to be left, found %s", entry.Name())
+		}
+	}
+	if got := stats.GetFilesMoved(); got != 1 {
+		t.Errorf("Expected 1 file moved, got %d", got)
+	}
+}
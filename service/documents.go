@@ -0,0 +1,92 @@
+// Package service - document metadata routing
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mohamedation/GoSorter/helpers"
+	"github.com/mohamedation/GoSorter/model"
+)
+
+// PDFs and office documents we can read metadata from
+var documentExtensions = map[string]bool{
+	".pdf":  true,
+	".docx": true,
+	".docm": true,
+	".xlsx": true,
+	".xlsm": true,
+	".pptx": true,
+	".pptm": true,
+	".odt":  true,
+	".ods":  true,
+	".odp":  true,
+	".odg":  true,
+}
+
+const unknownProducer = "Unknown Producer"
+
+func (fp *FileProcessor) documentMeta(file model.FileDetail) *helpers.DocumentMeta {
+	if !documentExtensions[file.Ext] {
+		return &helpers.DocumentMeta{}
+	}
+	meta, err := helpers.ReadDocumentMeta(file.Path)
+	if err != nil {
+		fp.Logger.Log(*fp.config, helpers.Debug, fmt.Sprintf("No document metadata in %s: %v\n", file.Name, err))
+		return &helpers.DocumentMeta{}
+	}
+	return meta
+}
+
+// documentValues - doc.* template tokens with fallbacks
+func (fp *FileProcessor) documentValues(file model.FileDetail) map[string]string {
+	meta := fp.documentMeta(file)
+	values := map[string]string{
+		"doc.title":    helpers.SanitizeName(meta.Title),
+		"doc.author":   helpers.SanitizeName(meta.Author),
+		"doc.producer": helpers.SanitizeName(meta.Producer),
+	}
+	if values["doc.title"] == "" {
+		values["doc.title"] = helpers.SanitizeName(strings.TrimSuffix(file.Name, file.Ext))
+	}
+	if values["doc.author"] == "" {
+		values["doc.author"] = unknownAuthor
+	}
+	if values["doc.producer"] == "" {
+		values["doc.producer"] = unknownProducer
+	}
+	return values
+}
+
+// documentTargetFolder - folder of the first matching document rule, or the mapped folder
+func (fp *FileProcessor) documentTargetFolder(file model.FileDetail, targetFolder string) string {
+	if len(fp.extConfig.DocumentRules) == 0 {
+		return targetFolder
+	}
+
+	meta := fp.documentMeta(file)
+	for _, rule := range fp.extConfig.DocumentRules {
+		if !documentRuleMatches(rule, meta) {
+			continue
+		}
+		if !helpers.HasDateTokens(rule.Folder) {
+			return rule.Folder
+		}
+		created := meta.Created.Local()
+		if meta.Created.IsZero() {
+			created = helpers.FileDate(file.Path, fp.extConfig.DateSource)
+		}
+		return helpers.ExpandDateTokens(rule.Folder, created)
+	}
+	return targetFolder
+}
+
+// missing metadata never matches a condition on it
+func documentRuleMatches(rule model.DocumentRule, meta *helpers.DocumentMeta) bool {
+	contains := func(value, want string) bool {
+		return want == "" || (value != "" && strings.Contains(strings.ToLower(value), strings.ToLower(want)))
+	}
+	return contains(meta.Producer, rule.Producer) &&
+		contains(meta.Author, rule.Author) &&
+		contains(meta.Title, rule.Title)
+}
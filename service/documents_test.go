@@ -0,0 +1,218 @@
+// Package service - document metadata tests
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mohamedation/GoSorter/helpers"
+	"github.com/mohamedation/GoSorter/model"
+)
+
+// buildPDF - minimal PDF with a plain Info object referenced from the trailer
+func buildPDF(info string) []byte {
+	return []byte(fmt.Sprintf(`%%PDF-1.4
+1 0 obj
+<< /Type /Catalog /Pages 2 0 R >>
+endobj
+2 0 obj
+<< /Type /Pages /Kids [] /Count 0 >>
+endobj
+3 0 obj
+<< %s >>
+endobj
+trailer
+<< /Size 4 /Root 1 0 R /Info 3 0 R >>
+%%%%EOF
+`, info))
+}
+
+func buildZip(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		fw, err := w.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to create zip entry: %v", err)
+		}
+		if _, err := fw.Write([]byte(content)); err != nil {
+			t.Fatalf("Failed to write zip entry: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func buildDOCX(t *testing.T, title, author string) []byte {
+	return buildZip(t, map[string]string{
+		"docProps/core.xml": `<?xml version="1.0"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/">
+  <dc:title>` + title + `</dc:title>
+  <dc:creator>` + author + `</dc:creator>
+  <dcterms:created>2022-11-05T09:30:00Z</dcterms:created>
+</cp:coreProperties>`,
+		"docProps/app.xml": `<?xml version="1.0"?>
+<Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/extended-properties"><Application>Microsoft Office Word</Application></Properties>`,
+	})
+}
+
+func buildODT(t *testing.T, title, author string) []byte {
+	return buildZip(t, map[string]string{
+		"meta.xml": `<?xml version="1.0"?>
+<office:document-meta xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:meta="urn:oasis:names:tc:opendocument:xmlns:meta:1.0" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <office:meta>
+    <meta:generator>LibreOffice/7.5</meta:generator>
+    <dc:title>` + title + `</dc:title>
+    <meta:initial-creator>` + author + `</meta:initial-creator>
+    <meta:creation-date>2021-04-02T10:00:00</meta:creation-date>
+  </office:meta>
+</office:document-meta>`,
+	})
+}
+
+func TestReadDocumentMeta(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_document_meta")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	xmpPDF := append(buildPDF(`/Producer (Acme\(R\) Scan)`), []byte(`<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF>
+<rdf:Description xmp:CreateDate="2020-01-15T12:00:00Z">
+<dc:title><rdf:Alt><rdf:li xml:lang="x-default">Tax &amp; Receipts</rdf:li></rdf:Alt></dc:title>
+</rdf:Description></rdf:RDF></x:xmpmeta>`)...)
+
+	tests := []struct {
+		file string
+		data []byte
+		want helpers.DocumentMeta
+	}{
+		{
+			"report.pdf",
+			buildPDF(`/Title (Quarterly Report) /Author <FEFF004A0061006E0065> /Producer (ScanSnap iX1600) /CreationDate (D:20230715083000+02'00')`),
+			helpers.DocumentMeta{Title: "Quarterly Report", Author: "Jane", Producer: "ScanSnap iX1600", Created: time.Date(2023, 7, 15, 8, 30, 0, 0, time.Local)},
+		},
+		{
+			"xmp.pdf",
+			xmpPDF,
+			helpers.DocumentMeta{Title: "Tax & Receipts", Producer: "Acme(R) Scan", Created: time.Date(2020, 1, 15, 12, 0, 0, 0, time.UTC)},
+		},
+		{
+			"letter.docx",
+			buildDOCX(t, "Offer Letter", "Acme Corp"),
+			helpers.DocumentMeta{Title: "Offer Letter", Author: "Acme Corp", Producer: "Microsoft Office Word", Created: time.Date(2022, 11, 5, 9, 30, 0, 0, time.UTC)},
+		},
+		{
+			"notes.odt",
+			buildODT(t, "Notes", "Sam"),
+			helpers.DocumentMeta{Title: "Notes", Author: "Sam", Producer: "LibreOffice/7.5", Created: time.Date(2021, 4, 2, 10, 0, 0, 0, time.UTC)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			filePath := filepath.Join(tempDir, tt.file)
+			if err := os.WriteFile(filePath, tt.data, 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+			got, err := helpers.ReadDocumentMeta(filePath)
+			if err != nil {
+				t.Fatalf("ReadDocumentMeta failed: %v", err)
+			}
+			if got.Title != tt.want.Title || got.Author != tt.want.Author || got.Producer != tt.want.Producer || !got.Created.Equal(tt.want.Created) {
+				t.Errorf("ReadDocumentMeta() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileProcessor_DocumentRules(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_document_rules")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	testFiles := map[string][]byte{
+		"scan0001.pdf":  buildPDF(`/Producer (ScanSnap iX1600) /CreationDate (D:20230715083000)`),
+		"contract.docx": buildDOCX(t, "Contract", "Acme Corp Legal"),
+		"download.pdf":  buildPDF(`/Producer (pdfTeX-1.40)`),
+		"broken.pdf":    []byte("not a pdf"),
+	}
+	for file, data := range testFiles {
+		if err := os.WriteFile(filepath.Join(tempDir, file), data, 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", file, err)
+		}
+	}
+
+	config := &model.Config{Silent: true}
+	stats := &model.Stats{StartTime: time.Now()}
+	processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+	processor.extConfig.DocumentRules = []model.DocumentRule{
+		{Folder: "Scans/{yyyy}", Producer: "scansnap"},
+		{Folder: "Work", Author: "acme corp"},
+	}
+
+	if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+		t.Fatalf("ProcessDirectory with document rules failed: %v", err)
+	}
+
+	expected := []string{
+		filepath.Join(tempDir, "Scans", "2023", "scan0001.pdf"),
+		filepath.Join(tempDir, "Work", "contract.docx"),
+		filepath.Join(tempDir, "PDFs", "download.pdf"),
+		filepath.Join(tempDir, "PDFs", "broken.pdf"),
+	}
+	for _, path := range expected {
+		if !helpers.FileExists(path) {
+			t.Errorf("Expected file at %s", path)
+		}
+	}
+}
+
+func TestFileProcessor_DocumentTemplate(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_document_template")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	if err := os.WriteFile(filepath.Join(tempDir, "doc1.odt"), buildODT(t, "Meeting Notes", "Sam"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	config := &model.Config{Silent: true}
+	stats := &model.Stats{StartTime: time.Now()}
+	processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+	processor.extConfig.DestinationTemplates = map[string]model.DestinationTemplate{
+		".odt": {Folder: "{category}/{doc.author}", FileName: "{doc.title}"},
+	}
+
+	if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+		t.Fatalf("ProcessDirectory with document template failed: %v", err)
+	}
+
+	expected := filepath.Join(tempDir, "Documents", "Sam", "Meeting Notes.odt")
+	if !helpers.FileExists(expected) {
+		t.Errorf("Expected file at %s", expected)
+	}
+}
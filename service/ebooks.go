@@ -0,0 +1,47 @@
+// Package service - ebook organization
+package service
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/mohamedation/GoSorter/helpers"
+	"github.com/mohamedation/GoSorter/model"
+)
+
+var ebookExtensions = map[string]bool{
+	".epub": true,
+	".mobi": true,
+	".azw":  true,
+	".azw3": true,
+}
+
+const unknownAuthor = "Unknown Author"
+
+// ebookDestination - author subfolder and/or title file name from the book's metadata
+func (fp *FileProcessor) ebookDestination(file model.FileDetail, targetFolder, newName string) (string, string) {
+	byAuthor := fp.extConfig.EbookLayout == model.EbookLayoutAuthor
+	if !byAuthor && !fp.extConfig.EbookRename {
+		return targetFolder, newName
+	}
+
+	meta, err := helpers.ReadEbookMeta(file.Path)
+	if err != nil {
+		fp.Logger.Log(*fp.config, helpers.Debug, fmt.Sprintf("No ebook metadata in %s: %v\n", file.Name, err))
+		meta = &helpers.EbookMeta{}
+	}
+
+	if byAuthor {
+		author := helpers.SanitizeName(meta.Author)
+		if author == "" {
+			author = unknownAuthor
+		}
+		targetFolder = filepath.Join(targetFolder, author)
+	}
+	if fp.extConfig.EbookRename {
+		if title := helpers.SanitizeName(meta.Title); title != "" {
+			newName = title + filepath.Ext(file.Name)
+		}
+	}
+	return targetFolder, newName
+}
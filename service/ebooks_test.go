@@ -0,0 +1,159 @@
+// Package service - ebook organization tests
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mohamedation/GoSorter/helpers"
+	"github.com/mohamedation/GoSorter/model"
+)
+
+func buildEPUB(t *testing.T, title, author string) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	files := []struct{ name, content string }{
+		{"mimetype", "application/epub+zip"},
+		{"META-INF/container.xml", `<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles>
+</container>`},
+		{"OEBPS/content.opf", `<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:title>` + title + `</dc:title>
+    <dc:creator>` + author + `</dc:creator>
+  </metadata>
+</package>`},
+	}
+	for _, f := range files {
+		fw, err := w.Create(f.name)
+		if err != nil {
+			t.Fatalf("Failed to create zip entry: %v", err)
+		}
+		if _, err := fw.Write([]byte(f.content)); err != nil {
+			t.Fatalf("Failed to write zip entry: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// buildMOBI - PalmDB with a single record holding the MOBI header, EXTH author and full name
+func buildMOBI(title, author string) []byte {
+	be := binary.BigEndian
+	const mobiHeaderLen = 232
+	record0Offset := 78 + 8
+
+	exth := []byte("EXTH")
+	rec := be.AppendUint32(nil, 100)
+	rec = be.AppendUint32(rec, uint32(8+len(author)))
+	rec = append(rec, author...)
+	exth = be.AppendUint32(exth, uint32(12+len(rec)))
+	exth = be.AppendUint32(exth, 1)
+	exth = append(exth, rec...)
+
+	record0 := make([]byte, 16+mobiHeaderLen)
+	copy(record0[16:], "MOBI")
+	be.PutUint32(record0[20:], mobiHeaderLen)
+	be.PutUint32(record0[28:], 65001)
+	be.PutUint32(record0[84:], uint32(len(record0)+len(exth)))
+	be.PutUint32(record0[88:], uint32(len(title)))
+	be.PutUint32(record0[128:], 0x40)
+	record0 = append(append(record0, exth...), title...)
+
+	palm := make([]byte, record0Offset)
+	copy(palm, "test")
+	copy(palm[60:], "BOOKMOBI")
+	be.PutUint16(palm[76:], 1)
+	be.PutUint32(palm[78:], uint32(record0Offset))
+	return append(palm, record0...)
+}
+
+func TestReadEbookMeta(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_ebook_meta")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	tests := []struct {
+		file string
+		data []byte
+		want helpers.EbookMeta
+	}{
+		{"9781234567890.epub", buildEPUB(t, "Dune", "Frank Herbert"), helpers.EbookMeta{Title: "Dune", Author: "Frank Herbert"}},
+		{"B00ABCDEF.azw3", buildMOBI("Neuromancer", "William Gibson"), helpers.EbookMeta{Title: "Neuromancer", Author: "William Gibson"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			filePath := filepath.Join(tempDir, tt.file)
+			if err := os.WriteFile(filePath, tt.data, 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+			got, err := helpers.ReadEbookMeta(filePath)
+			if err != nil {
+				t.Fatalf("ReadEbookMeta failed: %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("ReadEbookMeta() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileProcessor_EbookLayout(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_ebooks")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	testFiles := map[string][]byte{
+		"9781234567890.epub": buildEPUB(t, "Dune", "Frank Herbert"),
+		"B00ABCDEF.mobi":     buildMOBI("Neuromancer", "William Gibson"),
+		"broken.epub":        []byte("not a zip"),
+	}
+	for file, data := range testFiles {
+		if err := os.WriteFile(filepath.Join(tempDir, file), data, 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", file, err)
+		}
+	}
+
+	config := &model.Config{Silent: true}
+	stats := &model.Stats{StartTime: time.Now()}
+	processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+	processor.extConfig.EbookLayout = model.EbookLayoutAuthor
+	processor.extConfig.EbookRename = true
+
+	if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+		t.Fatalf("ProcessDirectory with ebook layout failed: %v", err)
+	}
+
+	expected := []string{
+		filepath.Join(tempDir, "Ebooks", "Frank Herbert", "Dune.epub"),
+		filepath.Join(tempDir, "Ebooks", "William Gibson", "Neuromancer.mobi"),
+		filepath.Join(tempDir, "Ebooks", "Unknown Author", "broken.epub"),
+	}
+	for _, path := range expected {
+		if !helpers.FileExists(path) {
+			t.Errorf("Expected file at %s", path)
+		}
+	}
+}
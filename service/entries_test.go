@@ -0,0 +1,187 @@
+// Package service - hidden, junk, symlink and special file tests
+package service
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mohamedation/GoSorter/helpers"
+	"github.com/mohamedation/GoSorter/model"
+)
+
+func TestFileProcessor_EntryPolicies(t *testing.T) {
+	tests := []struct {
+		name        string
+		hidden      string
+		junk        string
+		wantStay    []string
+		wantMoved   []string
+		wantGone    []string
+		wantSkipped int64
+	}{
+		{
+			name:        "defaults sort hidden, skip junk",
+			wantStay:    []string{".bashrc", ".DS_Store", "Thumbs.db", "desktop.ini"}, // .bashrc has an unknown extension
+			wantMoved:   []string{"PDFs/report.pdf", "PDFs/.hidden.pdf"},
+			wantSkipped: 3,
+		},
+		{
+			name:        "skip hidden",
+			hidden:      model.EntrySkip,
+			wantStay:    []string{".bashrc", ".hidden.pdf", ".DS_Store", "Thumbs.db", "desktop.ini"},
+			wantMoved:   []string{"PDFs/report.pdf"},
+			wantSkipped: 5,
+		},
+		{
+			name:        "sort hidden, delete junk",
+			hidden:      model.EntrySort,
+			junk:        model.EntryDelete,
+			wantStay:    []string{".bashrc"}, // unknown extension
+			wantMoved:   []string{"PDFs/report.pdf", "PDFs/.hidden.pdf"},
+			wantGone:    []string{".DS_Store", "Thumbs.db", "desktop.ini"},
+			wantSkipped: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir, err := os.MkdirTemp("", "gosorter_test_entries")
+			if err != nil {
+				t.Fatalf("Failed to create temp dir: %v", err)
+			}
+			defer func() {
+				if err := os.RemoveAll(tempDir); err != nil {
+					t.Fatalf("Failed to remove temp dir: %v", err)
+				}
+			}()
+			for _, name := range []string{"report.pdf", ".bashrc", ".hidden.pdf", ".DS_Store", "Thumbs.db", "desktop.ini"} {
+				if err := os.WriteFile(filepath.Join(tempDir, name), []byte(name), 0644); err != nil {
+					t.Fatalf("Failed to create test file: %v", err)
+				}
+			}
+
+			config := &model.Config{Silent: true}
+			stats := &model.Stats{StartTime: time.Now()}
+			processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+			if tt.hidden != "" {
+				processor.extConfig.HiddenFiles = tt.hidden
+			}
+			if tt.junk != "" {
+				processor.extConfig.JunkFiles = tt.junk
+			}
+			if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			for _, name := range tt.wantStay {
+				if !helpers.FileExists(filepath.Join(tempDir, name)) {
+					t.Errorf("Expected %s to stay in place", name)
+				}
+			}
+			for _, path := range tt.wantMoved {
+				if !helpers.FileExists(filepath.Join(tempDir, filepath.FromSlash(path))) {
+					t.Errorf("Expected %s", path)
+				}
+			}
+			for _, name := range tt.wantGone {
+				if _, err := os.Lstat(filepath.Join(tempDir, name)); !os.IsNotExist(err) {
+					t.Errorf("Expected %s to be deleted, got %v", name, err)
+				}
+			}
+			if got := stats.GetSkipped(); got != tt.wantSkipped {
+				t.Errorf("Expected %d skipped files, got %d", tt.wantSkipped, got)
+			}
+			if got := stats.GetJunkDeleted(); got != int64(len(tt.wantGone)) {
+				t.Errorf("Expected %d deleted junk files, got %d", len(tt.wantGone), got)
+			}
+		})
+	}
+}
+
+func TestFileProcessor_SymlinksAndSpecialFiles(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		config model.Config
+	}{
+		{"streamed", model.Config{Silent: true}},
+		{"duplicates", model.Config{Silent: true, MoveDuplicates: true}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tempDir, err := os.MkdirTemp("", "gosorter_test_symlinks")
+			if err != nil {
+				t.Fatalf("Failed to create temp dir: %v", err)
+			}
+			defer func() {
+				if err := os.RemoveAll(tempDir); err != nil {
+					t.Fatalf("Failed to remove temp dir: %v", err)
+				}
+			}()
+			sortDir := filepath.Join(tempDir, "sort")
+			outside := filepath.Join(tempDir, "outside")
+			for _, dir := range []string{sortDir, outside} {
+				if err := os.MkdirAll(dir, 0750); err != nil {
+					t.Fatalf("Failed to create dir: %v", err)
+				}
+			}
+			if err := os.WriteFile(filepath.Join(outside, "real.pdf"), []byte("real"), 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(sortDir, "report.pdf"), []byte("report"), 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+			links := map[string]string{
+				"link.pdf":     "../outside/real.pdf", // relative, rewritten when moved
+				"same.pdf":     "../outside/real.pdf", // same target as link.pdf, but not a duplicate
+				"folder.pdf":   "../outside",          // links to directories are never moved
+				"absolute.pdf": filepath.Join(outside, "real.pdf"),
+			}
+			for name, target := range links {
+				if err := os.Symlink(target, filepath.Join(sortDir, name)); err != nil {
+					t.Skipf("Symlinks not available: %v", err)
+				}
+			}
+			// opening a socket for hashing fails, a FIFO would block
+			if listener, err := net.Listen("unix", filepath.Join(sortDir, "app.sock")); err == nil {
+				defer func() { _ = listener.Close() }()
+			}
+
+			config := tc.config
+			stats := &model.Stats{StartTime: time.Now()}
+			processor := NewFileProcessor(&config, stats, &helpers.CLILogger{})
+			processor.extConfig.Symlinks = model.EntrySort
+			processor.extConfig.RewriteSymlinks = true
+			if err := processor.ProcessDirectory(context.Background(), sortDir); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			for _, name := range []string{"link.pdf", "same.pdf", "absolute.pdf", "report.pdf"} {
+				content, err := os.ReadFile(filepath.Join(sortDir, "PDFs", name))
+				if err != nil {
+					t.Errorf("Expected PDFs/%s to be readable: %v", name, err)
+					continue
+				}
+				if name != "report.pdf" {
+					if info, err := os.Lstat(filepath.Join(sortDir, "PDFs", name)); err != nil || info.Mode()&os.ModeSymlink == 0 {
+						t.Errorf("Expected PDFs/%s to still be a symlink", name)
+					}
+				}
+				if name == "link.pdf" && string(content) != "real" {
+					t.Errorf("Expected the rewritten link to point to real.pdf, got %q", content)
+				}
+			}
+			if target, err := os.Readlink(filepath.Join(sortDir, "PDFs", "link.pdf")); err != nil || target != filepath.Join("..", "..", "outside", "real.pdf") {
+				t.Errorf("Expected the relative target to be rewritten, got %q (%v)", target, err)
+			}
+			if _, err := os.Lstat(filepath.Join(sortDir, "folder.pdf")); err != nil {
+				t.Errorf("Expected the link to a directory to stay: %v", err)
+			}
+			if got := stats.GetDuplicatesMoved(); got != 0 {
+				t.Errorf("Expected links not to be duplicates, got %d", got)
+			}
+		})
+	}
+}
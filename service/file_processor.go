@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 
@@ -19,20 +20,27 @@ type FileProcessor struct {
 	stats     *model.Stats
 	extConfig *model.ExtensionConfig
 	Logger    helpers.Logger
-}
 
-// move a file to its appropriate target folder
-func (fp *FileProcessor) moveFileToTargetFolder(folderPath string, file model.FileDetail) error {
-	return fp.moveFileToFolder(folderPath, file, fp.extConfig)
+	configErr error                    // extension.json could not be read, parsed or validated
+	sidecars  map[string][]sidecarFile // lowercase primary file name -> its companion files, see groupSidecars
+	prompter  *conflictPrompter        // answers the prompt conflict policy
+	ignore    *model.IgnoreRules       // .gosorterignore and the global ignore file
 }
 
 // NewFileProcessor -  file processor instance
 func NewFileProcessor(config *model.Config, stats *model.Stats, logger helpers.Logger) *FileProcessor {
+	extConfig, err := model.ReadExtensionConfig()
+	if err != nil {
+		// ProcessDirectory refuses to run, the defaults are only there for the fields
+		extConfig = model.DefaultExtensionConfig()
+	}
 	return &FileProcessor{
 		config:    config,
 		stats:     stats,
-		extConfig: model.LoadExtensionConfig(),
+		extConfig: extConfig,
 		Logger:    logger,
+		configErr: err,
+		prompter:  newConflictPrompter(os.Stdin, os.Stdout),
 	}
 }
 
@@ -42,11 +50,70 @@ func (fp *FileProcessor) ProcessDirectory(ctx context.Context, folderPath string
 		return fmt.Errorf("directory '%s' does not exist", folderPath)
 	}
 
-	entries, err := os.ReadDir(folderPath)
+	// "gosorter" in a source tree or the home directory scatters files nobody wanted sorted
+	if !fp.config.Force {
+		if err := helpers.CheckRoot(folderPath); err != nil {
+			return fmt.Errorf("%w, use -f to sort it anyway", err)
+		}
+	}
+
+	if fp.configErr != nil {
+		return fmt.Errorf("invalid extension configuration:\n  %w", fp.configErr)
+	}
+	if err := fp.extConfig.Validate(); err != nil {
+		return fmt.Errorf("invalid extension configuration:\n  %w", err)
+	}
+
+	// a second GoSorter in the same directory would race for the same files and destinations
+	lock, err := helpers.LockDirectory(folderPath, *fp.config, fp.Logger)
 	if err != nil {
-		return fmt.Errorf("error reading directory: %w", err)
+		return err
+	}
+	defer func() {
+		if err := lock.Unlock(); err != nil {
+			fp.Logger.Log(*fp.config, helpers.Error, fmt.Sprintf("Failed to remove lock file: %v\n", err))
+		}
+	}()
+
+	fp.ignore, err = model.LoadIgnore(folderPath)
+	if err != nil {
+		return fmt.Errorf("invalid ignore file: %w", err)
+	}
+	// files still being downloaded or written are left for the next run
+	guard := fp.newWriteGuard(folderPath)
+
+	if err := fp.preflight(ctx, folderPath, guard); err != nil {
+		return err
+	}
+
+	if !fp.config.MoveDuplicates && !fp.config.ConsolidateCopies {
+		// nothing compares files with each other: stream the listing straight into the pipeline
+		sidecars, err := fp.findSidecars(ctx, folderPath, guard)
+		if err != nil {
+			return err
+		}
+		return fp.processFilesWithoutDuplicates(ctx, folderPath, func(yield func(os.DirEntry) bool) error {
+			return scanDirectory(ctx, folderPath, func(entry os.DirEntry) bool {
+				return sidecars[entry.Name()] || fp.skipEntry(guard, entry) || yield(entry)
+			})
+		})
 	}
 
+	entries, err := readDirectory(ctx, folderPath)
+	if err != nil {
+		return err
+	}
+	entries = fp.withoutSkipped(guard, entries)
+
+	if fp.config.ConsolidateCopies {
+		entries, err = fp.consolidateCopies(ctx, folderPath, entries)
+		if err != nil {
+			return err
+		}
+	}
+
+	entries = fp.groupSidecars(entries)
+
 	return fp.processFiles(ctx, folderPath, entries)
 }
 
@@ -55,31 +122,62 @@ func (fp *FileProcessor) processFiles(ctx context.Context, folderPath string, en
 	if fp.config.MoveDuplicates {
 		return fp.processFilesWithDuplicates(ctx, folderPath, entries)
 	}
-	return fp.processFilesWithoutDuplicates(ctx, folderPath, entries)
+	return fp.processFilesWithoutDuplicates(ctx, folderPath, listEntries(entries))
 }
 
 // duplicate detection enabled
-func (fp *FileProcessor) processFilesWithDuplicates(ctx context.Context, folderPath string, entries []os.DirEntry) error {
+func (fp *FileProcessor) processFilesWithDuplicates(ctx context.Context, folderPath string, entries []os.DirEntry) (err error) {
+	// hashes and kept originals are saved as they are found, see resume
+	checkpoint := fp.openCheckpoint(folderPath)
+	defer func() { checkpoint.finish(err) }()
+
 	// group by size to check duplicates
 	fp.Logger.Log(*fp.config, helpers.Debug, "[DEBUG] Grouping files by size\n")
 	sizeGroups := make(map[int64][]os.DirEntry)
+	infos := make(map[string]os.FileInfo)
+	fileHashes := make(map[string][]model.FileDetail)
+	fileDetails := []model.FileDetail{}
 	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		if entry.IsDir() {
 			continue
 		}
 		filePath := filepath.Join(folderPath, entry.Name())
-		info, err := os.Stat(filePath)
+		// a link has the content of its target, but is never a duplicate of it
+		if entry.Type()&os.ModeSymlink != 0 {
+			uniqueHash := "symlink-" + entry.Name()
+			detail := model.FileDetail{
+				Name: entry.Name(),
+				Path: filePath,
+				Hash: uniqueHash,
+				Ext:  strings.ToLower(filepath.Ext(entry.Name())),
+			}
+			fileDetails = append(fileDetails, detail)
+			fileHashes[uniqueHash] = append(fileHashes[uniqueHash], detail)
+			fp.stats.IncrementTotalFiles()
+			continue
+		}
+		info, err := entry.Info()
 		if err != nil {
 			fp.stats.IncrementErrors()
 			fp.Logger.Log(*fp.config, helpers.Error, fmt.Sprintf("Failed to stat file %s: %v\n", filePath, err))
 			continue
 		}
+		// resumed run: the original of this content was already kept before the interruption
+		if original := checkpoint.duplicateOf(filePath, info); original != "" {
+			if dstPath := helpers.MoveDuplicateFile(ctx, folderPath, entry.Name(), original, fp.extConfig.DuplicatesFolder, *fp.config, fp.Logger); dstPath != "" {
+				fp.stats.IncrementTotalFiles()
+				fp.stats.IncrementDuplicatesMoved()
+				fp.moveSidecars(ctx, folderPath, entry.Name(), dstPath)
+			}
+			continue
+		}
+		infos[entry.Name()] = info
 		sizeGroups[info.Size()] = append(sizeGroups[info.Size()], entry)
 	}
 
-	fileHashes := make(map[string][]model.FileDetail)
-	fileDetails := []model.FileDetail{}
-
 	type hashResult struct {
 		detail model.FileDetail
 		err    error
@@ -114,12 +212,23 @@ func (fp *FileProcessor) processFilesWithDuplicates(ctx context.Context, folderP
 		// part. hash (still reconsidering if needed or just an extra step)
 		partialHashes := make(map[string][]os.DirEntry)
 		for _, entry := range group {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
 			filePath := filepath.Join(folderPath, entry.Name())
-			partialHash, err := helpers.PartialHashFile(filePath, partialHashSize, *fp.config, fp.Logger)
-			if err != nil {
-				fp.stats.IncrementErrors()
-				fp.Logger.Log(*fp.config, helpers.Error, fmt.Sprintf("Failed to partial hash file %s: %v\n", filePath, err))
-				continue
+			partialHash := checkpoint.lookup(entry.Name(), infos[entry.Name()]).Partial
+			if partialHash == "" {
+				var err error
+				partialHash, err = helpers.PartialHashFile(ctx, filePath, partialHashSize, *fp.config, fp.Logger)
+				if err != nil {
+					if ctx.Err() != nil {
+						return ctx.Err()
+					}
+					fp.stats.IncrementErrors()
+					fp.Logger.Log(*fp.config, helpers.Error, fmt.Sprintf("Failed to partial hash file %s: %v\n", filePath, err))
+					continue
+				}
+				checkpoint.setPartial(entry.Name(), infos[entry.Name()], partialHash)
 			}
 			partialHashes[partialHash] = append(partialHashes[partialHash], entry)
 		}
@@ -129,13 +238,15 @@ func (fp *FileProcessor) processFilesWithDuplicates(ctx context.Context, folderP
 			if len(pGroup) == 1 {
 				entry := pGroup[0]
 				filePath := filepath.Join(folderPath, entry.Name())
+				uniqueHash := "size-partial-" + fmt.Sprint(size) + "-" + pHash
 				detail := model.FileDetail{
 					Name: entry.Name(),
 					Path: filePath,
+					Hash: uniqueHash,
 					Ext:  strings.ToLower(filepath.Ext(entry.Name())),
 				}
 				fileDetails = append(fileDetails, detail)
-				fileHashes["size-partial-"+fmt.Sprint(size)+"-"+pHash] = append(fileHashes["size-partial-"+fmt.Sprint(size)+"-"+pHash], detail)
+				fileHashes[uniqueHash] = append(fileHashes[uniqueHash], detail)
 				fp.stats.IncrementTotalFiles()
 				continue
 			}
@@ -150,8 +261,18 @@ func (fp *FileProcessor) processFilesWithDuplicates(ctx context.Context, folderP
 			worker := func() {
 				defer wg.Done()
 				for entry := range jobs {
+					if ctx.Err() != nil {
+						continue
+					}
 					filePath := filepath.Join(folderPath, entry.Name())
-					hash, err := helpers.HashFile(filePath, maxBytes, *fp.config, fp.Logger)
+					hash := checkpoint.lookup(entry.Name(), infos[entry.Name()]).Full
+					var err error
+					if hash == "" {
+						hash, err = helpers.HashFile(ctx, filePath, maxBytes, *fp.config, fp.Logger)
+						if err == nil && hash != "" {
+							checkpoint.setFull(entry.Name(), infos[entry.Name()], hash)
+						}
+					}
 					detail := model.FileDetail{
 						Name: entry.Name(),
 						Path: filePath,
@@ -184,6 +305,10 @@ func (fp *FileProcessor) processFilesWithDuplicates(ctx context.Context, folderP
 
 			for res := range results {
 				if res.err != nil {
+					if ctx.Err() != nil {
+						// cut short by the interruption, not a failure
+						continue
+					}
 					fp.stats.IncrementErrors()
 					fp.Logger.Log(*fp.config, helpers.Error, fmt.Sprintf("Failed to hash file %s: %v\n", res.detail.Path, res.err))
 					continue
@@ -192,57 +317,60 @@ func (fp *FileProcessor) processFilesWithDuplicates(ctx context.Context, folderP
 				fileHashes[res.detail.Hash] = append(fileHashes[res.detail.Hash], res.detail)
 				fp.stats.IncrementTotalFiles()
 			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			checkpoint.maybeSave()
 		}
 	}
+	checkpoint.save()
 
 	// process
-	return fp.processFileGroups(folderPath, fileDetails, fileHashes)
+	return fp.processFileGroups(ctx, folderPath, fileDetails, fileHashes, checkpoint)
 }
 
-// processes files normally
-func (fp *FileProcessor) processFilesWithoutDuplicates(ctx context.Context, folderPath string, entries []os.DirEntry) error {
-	for _, entry := range entries {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
-
-		if entry.IsDir() {
-			continue
-		}
-
-		filePath := filepath.Join(folderPath, entry.Name())
-		detail := model.FileDetail{
-			Name: entry.Name(),
-			Path: filePath,
-			Ext:  strings.ToLower(filepath.Ext(entry.Name())),
-		}
-
-		fp.stats.IncrementTotalFiles()
-
-		// unknown extension
-		if _, ok := fp.extConfig.ExtensionToFolder[detail.Ext]; !ok {
-			fp.stats.IncrementUnknownExtensions(detail.Ext)
-			fp.Logger.Log(*fp.config, helpers.Debug, fmt.Sprintf("Skipping file with unknown extension: %s (%s)\n", detail.Name, detail.Ext))
-			continue
-		}
-
-		if err := fp.moveFileToTargetFolder(folderPath, detail); err != nil {
-			fp.stats.IncrementErrors()
-			fp.Logger.Log(*fp.config, helpers.Error, fmt.Sprintf("Failed to move file: %v\n", err))
-			continue
-		}
-		fp.stats.IncrementFilesMoved()
+// processes files normally, scan feeds the entries (streamed directory or a read listing)
+func (fp *FileProcessor) processFilesWithoutDuplicates(ctx context.Context, folderPath string, scan func(func(os.DirEntry) bool) error) error {
+	files := make(chan model.FileDetail, model.DefaultBufferSize)
+	scanErr := make(chan error, 1)
+	go func() {
+		defer close(files)
+		scanErr <- scan(func(entry os.DirEntry) bool {
+			if entry.IsDir() {
+				return true
+			}
+			detail := model.FileDetail{
+				Name: entry.Name(),
+				Path: filepath.Join(folderPath, entry.Name()),
+				Ext:  strings.ToLower(filepath.Ext(entry.Name())),
+			}
+			fp.stats.IncrementTotalFiles()
+			select {
+			case files <- detail:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+	if err := fp.sortFiles(ctx, folderPath, files); err != nil {
+		return err
 	}
-	return nil
+	return <-scanErr
 }
 
-// handles file groups
-func (fp *FileProcessor) processFileGroups(folderPath string, fileDetails []model.FileDetail, fileHashes map[string][]model.FileDetail) error {
+// handles file groups, duplicates are moved right away and the originals go through the sorting pipeline
+func (fp *FileProcessor) processFileGroups(ctx context.Context, folderPath string, fileDetails []model.FileDetail, fileHashes map[string][]model.FileDetail, checkpoint *checkpointer) error {
+	// hashing order depends on map iteration and worker timing
+	sort.Slice(fileDetails, func(i, j int) bool { return fileDetails[i].Name < fileDetails[j].Name })
+
 	processedHashes := make(map[string]bool)
+	var toSort []model.FileDetail
 
 	for _, detail := range fileDetails {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		if detail.Hash == "" {
 			fp.Logger.Log(*fp.config, helpers.Debug, fmt.Sprintf("Skipping file %s due to empty hash\n", detail.Path))
 			continue
@@ -250,6 +378,7 @@ func (fp *FileProcessor) processFileGroups(folderPath string, fileDetails []mode
 		if processedHashes[detail.Hash] {
 			continue
 		}
+		processedHashes[detail.Hash] = true
 
 		files := fileHashes[detail.Hash]
 		if len(files) == 0 {
@@ -257,70 +386,134 @@ func (fp *FileProcessor) processFileGroups(folderPath string, fileDetails []mode
 			continue
 		}
 
-		if len(files) == 1 {
-			if fp.config.DuplicatesOnly {
-				continue
-			}
-			original := files[0]
-			if _, ok := fp.extConfig.ExtensionToFolder[original.Ext]; !ok {
-				fp.stats.IncrementUnknownExtensions(original.Ext)
-				fp.Logger.Log(*fp.config, helpers.Debug, fmt.Sprintf("Skipping file with unknown extension: %s (%s)\n", original.Name, original.Ext))
-				continue
-			}
-			if err := fp.moveFileToFolder(folderPath, original, fp.extConfig); err != nil {
-				fp.stats.IncrementErrors()
-				fp.Logger.Log(*fp.config, helpers.Error, fmt.Sprintf("Failed to move file: %v\n", err))
-			} else {
-				fp.stats.IncrementFilesMoved()
-			}
-			processedHashes[detail.Hash] = true
-			continue
-		}
-
 		original := files[0]
 		for _, file := range files {
-			if len(file.Name) < len(original.Name) {
+			if len(file.Name) < len(original.Name) || (len(file.Name) == len(original.Name) && file.Name < original.Name) {
 				original = file
 			}
 		}
 
+		if len(files) > 1 {
+			checkpoint.setOriginal(detail.Hash, original.Path)
+			checkpoint.maybeSave()
+		}
 		for _, file := range files {
 			if file.Path == original.Path {
 				continue
 			}
-			helpers.MoveDuplicateFile(folderPath, file.Name, original.Path, *fp.config, fp.Logger)
-			fp.stats.IncrementDuplicatesMoved()
+			// sidecars were taken out of the listing, they go along to Duplicates
+			if dstPath := helpers.MoveDuplicateFile(ctx, folderPath, file.Name, original.Path, fp.extConfig.DuplicatesFolder, *fp.config, fp.Logger); dstPath != "" {
+				fp.stats.IncrementDuplicatesMoved()
+				fp.moveSidecars(ctx, folderPath, file.Name, dstPath)
+			}
 		}
 
 		if fp.config.DuplicatesOnly {
-			fp.Logger.Log(*fp.config, helpers.Debug, fmt.Sprintf("Duplicates-only mode: leaving original file %s in place\n", original.Name))
-		} else {
-			// Normal mode: move original file to appropriate folder
-			if _, ok := fp.extConfig.ExtensionToFolder[original.Ext]; !ok {
-				fp.stats.IncrementUnknownExtensions(original.Ext)
-				fp.Logger.Log(*fp.config, helpers.Debug, fmt.Sprintf("Skipping file with unknown extension: %s (%s)\n", original.Name, original.Ext))
-				processedHashes[detail.Hash] = true
-				continue
+			if len(files) > 1 {
+				fp.Logger.Log(*fp.config, helpers.Debug, fmt.Sprintf("Duplicates-only mode: leaving original file %s in place\n", original.Name))
 			}
-			if err := fp.moveFileToFolder(folderPath, original, fp.extConfig); err != nil {
-				fp.stats.IncrementErrors()
-				fp.Logger.Log(*fp.config, helpers.Error, fmt.Sprintf("Failed to move file: %v\n", err))
-			} else {
-				fp.stats.IncrementFilesMoved()
+			continue
+		}
+		toSort = append(toSort, original)
+	}
+	checkpoint.save()
+
+	files := make(chan model.FileDetail, model.DefaultBufferSize)
+	go func() {
+		defer close(files)
+		for _, file := range toSort {
+			select {
+			case files <- file:
+			case <-ctx.Done():
+				return
 			}
 		}
-		processedHashes[detail.Hash] = true
+	}()
+	return fp.sortFiles(ctx, folderPath, files)
+}
+
+// date subfolders, either from {yyyy}/{mm}/{dd} in the mapping or the global date_subfolders
+func (fp *FileProcessor) datedTargetFolder(file model.FileDetail, targetFolder string) string {
+	if fp.extConfig.DateSubfolders != "" {
+		targetFolder = filepath.Join(targetFolder, fp.extConfig.DateSubfolders)
 	}
-	return nil
+	if !helpers.HasDateTokens(targetFolder) {
+		return targetFolder
+	}
+	date := helpers.FileDate(file.Path, fp.extConfig.DateSource)
+	return filepath.Clean(helpers.ExpandDateTokens(targetFolder, date))
 }
 
-// move files to their appropriate folder
-func (fp *FileProcessor) moveFileToFolder(folderPath string, file model.FileDetail, config *model.ExtensionConfig) error {
+// placement - where a file goes, decided by classify and carried out by place
+type placement struct {
+	file         model.FileDetail
+	targetFolder string
+	newName      string
+	extracted    bool // archive next to its extracted folder, goes to archives_extracted_folder
+	transparent  bool
+	screenshot   bool
+}
+
+// folder - destination folder relative to the sorted directory
+func (p placement) folder() string {
+	return filepath.Clean(p.targetFolder)
+}
+
+// classify - inspects a file (metadata, transparency, screenshots) and decides where it goes, nothing is moved
+func (fp *FileProcessor) classify(ctx context.Context, folderPath string, file model.FileDetail, config *model.ExtensionConfig) (placement, error) {
 	targetFolder, ok := config.ExtensionToFolder[file.Ext]
 	if !ok {
 		fp.stats.IncrementUnknownExtensions(file.Ext)
 		fp.Logger.Log(*fp.config, helpers.Debug, fmt.Sprintf("Skipping file with unknown extension: %s (%s)\n", file.Name, file.Ext))
-		return fmt.Errorf("unknown extension: %s", file.Ext)
+		return placement{}, fmt.Errorf("unknown extension: %s", file.Ext)
+	}
+
+	newName := file.Name
+	template := config.TemplateFor(file.Ext)
+	if template.Folder != "" || template.FileName != "" {
+		values := fp.templateValues(ctx, file, targetFolder, template.Folder+template.FileName)
+		if template.Folder != "" {
+			targetFolder = filepath.Clean(helpers.ExpandFolderTemplate(template.Folder, values, unknownFolder))
+		}
+		if template.FileName != "" {
+			if stem := helpers.SanitizeName(helpers.ExpandTemplate(template.FileName, values)); stem != "" {
+				newName = stem + filepath.Ext(file.Name)
+			}
+		}
+	}
+	if template.Folder == "" {
+		if exifExtensions[file.Ext] {
+			targetFolder = fp.photoTargetFolder(file, targetFolder)
+		}
+		if videoExtensions[file.Ext] {
+			targetFolder = fp.videoTargetFolder(file, targetFolder)
+		}
+		if documentExtensions[file.Ext] {
+			targetFolder = fp.documentTargetFolder(file, targetFolder)
+		}
+		if musicExtensions[file.Ext] || ebookExtensions[file.Ext] {
+			var metaName string
+			if musicExtensions[file.Ext] {
+				targetFolder, metaName = fp.musicDestination(file, targetFolder, newName)
+			} else {
+				targetFolder, metaName = fp.ebookDestination(file, targetFolder, newName)
+			}
+			if template.FileName == "" {
+				newName = metaName
+			}
+		}
+		targetFolder = fp.datedTargetFolder(file, targetFolder)
+	}
+
+	p := placement{file: file, targetFolder: targetFolder, newName: newName}
+
+	if fp.config.DetectScreenshots {
+		if folder := fp.screenCaptureFolder(file); folder != "" {
+			fp.Logger.Log(*fp.config, helpers.Debug, fmt.Sprintf("%s is a screen capture, moving to %s\n", file.Name, folder))
+			p.targetFolder = fp.datedTargetFolder(file, folder)
+			p.screenshot = true
+			return p, nil
+		}
 	}
 
 	switch file.Ext {
@@ -328,11 +521,10 @@ func (fp *FileProcessor) moveFileToFolder(folderPath string, file model.FileDeta
 		dirName := strings.TrimSuffix(file.Name, file.Ext)
 		dirPath := filepath.Join(folderPath, dirName)
 		if helpers.FolderExists(dirPath) {
-			helpers.MoveExtractedArchive(folderPath, file.Name, *fp.config, fp.Logger)
-			return nil
+			p.extracted, p.targetFolder = true, config.ArchiveExtractedFolder
+			return p, nil
 		}
-		helpers.MoveFileToTargetFolder(folderPath, file.Name, "Archives", *fp.config, fp.Logger)
-	case ".png":
+	case ".png", ".gif", ".webp":
 		if fp.config.DetectTransparentPNGs {
 			hasTransparency, err := helpers.HasTransparency(file.Path, *fp.config, fp.Logger)
 			if err != nil {
@@ -341,18 +533,68 @@ func (fp *FileProcessor) moveFileToFolder(folderPath string, file model.FileDeta
 				hasTransparency = false
 			}
 			if hasTransparency {
-				fp.stats.IncrementTransparentPNGsMoved()
-				fp.Logger.Log(*fp.config, helpers.Debug, fmt.Sprintf("PNG file %s has transparency, moving to %s\n", file.Name, config.TransparentPNGFolder))
-				helpers.MoveFileToTargetFolder(folderPath, file.Name, config.TransparentPNGFolder, *fp.config, fp.Logger)
+				fp.Logger.Log(*fp.config, helpers.Debug, fmt.Sprintf("Image %s has transparency, moving to %s\n", file.Name, config.TransparentPNGFolder))
+				p.targetFolder = config.TransparentPNGFolder
+				p.transparent = true
 			} else {
-				fp.Logger.Log(*fp.config, helpers.Debug, fmt.Sprintf("PNG file %s has no transparency, moving to %s\n", file.Name, targetFolder))
-				helpers.MoveFileToTargetFolder(folderPath, file.Name, targetFolder, *fp.config, fp.Logger)
+				fp.Logger.Log(*fp.config, helpers.Debug, fmt.Sprintf("Image %s has no transparency, moving to %s\n", file.Name, targetFolder))
 			}
-		} else {
-			helpers.MoveFileToTargetFolder(folderPath, file.Name, targetFolder, *fp.config, fp.Logger)
 		}
-	default:
-		helpers.MoveFileToTargetFolder(folderPath, file.Name, targetFolder, *fp.config, fp.Logger)
 	}
-	return nil
+	return p, nil
+}
+
+// place - moves a classified file and its sidecars, reports whether the file was moved
+func (fp *FileProcessor) place(ctx context.Context, folderPath string, p placement) bool {
+	var dstPath string
+	outcome := helpers.Placed
+	if p.extracted {
+		dstPath = helpers.MoveExtractedArchive(ctx, folderPath, p.file.Name, p.targetFolder, *fp.config, fp.Logger)
+		if dstPath == "" {
+			outcome = helpers.Failed
+		}
+	} else {
+		dstPath, outcome = helpers.PlaceFile(ctx, folderPath, p.file.Name, p.targetFolder, p.newName, fp.conflictFor(p.file.Ext), *fp.config, fp.Logger)
+	}
+	if fp.extConfig.RewriteSymlinks && (outcome == helpers.Placed || outcome == helpers.Duplicated) {
+		if err := helpers.RetargetSymlink(dstPath, folderPath); err != nil {
+			fp.stats.IncrementErrors()
+			fp.Logger.Log(*fp.config, helpers.Error, fmt.Sprintf("Failed to rewrite symlink %s: %v\n", dstPath, err))
+		}
+	}
+
+	switch outcome {
+	case helpers.Skipped:
+		fp.stats.IncrementConflictsSkipped()
+		return false
+	case helpers.Unchanged:
+		return false
+	case helpers.Duplicated:
+		fp.stats.IncrementDuplicatesMoved()
+		fp.moveSidecars(ctx, folderPath, p.file.Name, dstPath)
+		return false
+	case helpers.Failed:
+		// an interrupted move is not an error, the file is still where it was
+		if ctx.Err() == nil {
+			fp.stats.IncrementErrors()
+		}
+		return false
+	}
+	if p.screenshot {
+		fp.stats.IncrementScreenshotsMoved()
+	}
+	if p.transparent {
+		fp.stats.IncrementTransparentPNGsMoved()
+	}
+	fp.moveSidecars(ctx, folderPath, p.file.Name, dstPath)
+	return true
+}
+
+// conflictFor - conflict policy of an extension, prompts are answered on the terminal
+func (fp *FileProcessor) conflictFor(ext string) model.Conflict {
+	conflict := fp.extConfig.ConflictFor(ext, fp.config.ConflictPolicy)
+	if conflict.Policy == model.ConflictPrompt && fp.prompter != nil {
+		conflict.Ask = fp.prompter.ask
+	}
+	return conflict
 }
@@ -158,72 +158,52 @@ func TestFileProcessor_ProcessFilesWithoutDuplicates(t *testing.T) {
 	}
 }
 
-// test for transparent PNG detection, but needs valid PNG data so still WIP
-// func TestFileProcessor_TransparentPNGDetection(t *testing.T) {
-// 	tempDir, err := os.MkdirTemp("", "gosorter_test_png")
-// 	if err != nil {
-// 		t.Fatalf("Failed to create temp dir: %v", err)
-// 	}
-// 	defer os.RemoveAll(tempDir)
-
-// 	// generated with AI, but still not valid, yet
-// 	// Transparent PNG (1x1 pixel with alpha=0)
-// 	transparentPNG := []byte{
-// 		0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A, // PNG signature
-// 		0x00, 0x00, 0x00, 0x0D, 0x49, 0x48, 0x44, 0x52, // IHDR chunk
-// 		0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01, // 1x1 pixels
-// 		0x08, 0x06, 0x00, 0x00, 0x00, 0x1F, 0x15, 0xC4, // RGBA, no interlace
-// 		0x89, 0x00, 0x00, 0x00, 0x0D, 0x49, 0x44, 0x41, // IDAT chunk
-// 		0x54, 0x78, 0x9C, 0x62, 0x00, 0x02, 0x00, 0x00, // Compressed data (transparent pixel)
-// 		0x05, 0x00, 0x01, 0x0D, 0x0A, 0x2D, 0xB4, 0x00,
-// 		0x00, 0x00, 0x00, 0x49, 0x45, 0x4E, 0x44, 0xAE, // IEND chunk
-// 		0x42, 0x60, 0x82,
-// 	}
-
-// 	// Opaque PNG (1x1 pixel with alpha=255)
-// 	opaquePNG := []byte{
-// 		0x89, 0x50, 0x4E, 0x47, 0x0D, 0x0A, 0x1A, 0x0A, // PNG signature
-// 		0x00, 0x00, 0x00, 0x0D, 0x49, 0x48, 0x44, 0x52, // IHDR chunk
-// 		0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01, // 1x1 pixels
-// 		0x08, 0x06, 0x00, 0x00, 0x00, 0x1F, 0x15, 0xC4, // RGBA, no interlace
-// 		0x89, 0x00, 0x00, 0x00, 0x0D, 0x49, 0x44, 0x41, // IDAT chunk
-// 		0x54, 0x78, 0x9C, 0x62, 0xF8, 0x0F, 0x00, 0x00, // Compressed data (opaque pixel)
-// 		0xFF, 0xFF, 0x01, 0x00, 0x01, 0x00, 0x01, 0x02,
-// 		0x7E, 0x00, 0x00, 0x00, 0x00, 0x49, 0x45, 0x4E, // IEND chunk
-// 		0x44, 0xAE, 0x42, 0x60, 0x82,
-// 	}
-
-// 	transparentPath := filepath.Join(tempDir, "transparent.png")
-// 	opaquePath := filepath.Join(tempDir, "opaque.png")
-
-// 	if err := os.WriteFile(transparentPath, transparentPNG, 0644); err != nil {
-// 		t.Fatalf("Failed to create transparent PNG file: %v", err)
-// 	}
-
-// 	if err := os.WriteFile(opaquePath, opaquePNG, 0644); err != nil {
-// 		t.Fatalf("Failed to create opaque PNG file: %v", err)
-// 	}
-
-// 	config := &model.Config{
-// 		MoveDuplicates:        false,
-// 		Verbose:               true,
-// 		Silent:                false,
-// 		DetectTransparentPNGs: true,
-// 	}
-// 	stats := &model.Stats{StartTime: time.Now()}
-
-// 	processor := NewFileProcessor(config, stats)
-// 	ctx := context.Background()
-
-// 	err = processor.ProcessDirectory(ctx, tempDir)
-// 	if err != nil {
-// 		t.Errorf("ProcessDirectory with PNG detection failed: %v", err)
-// 	}
-
-// 	if stats.GetTotalFiles() != 2 {
-// 		t.Errorf("Expected 2 files processed, got %d", stats.GetTotalFiles())
-// 	}
-// }
+func TestFileProcessor_TransparentPNGDetection(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_png")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	testFiles := map[string][]byte{
+		"transparent.png": buildNRGBAPNG(t, 100),
+		"opaque.png":      buildNRGBAPNG(t, 0),
+		"sticker.gif":     buildTransparentGIF(t),
+		"sticker.webp":    buildWebPLossless(0),
+	}
+	for file, data := range testFiles {
+		if err := os.WriteFile(filepath.Join(tempDir, file), data, 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", file, err)
+		}
+	}
+
+	config := &model.Config{Silent: true, DetectTransparentPNGs: true}
+	stats := &model.Stats{StartTime: time.Now()}
+	processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+
+	if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+		t.Fatalf("ProcessDirectory with PNG detection failed: %v", err)
+	}
+
+	expected := []string{
+		filepath.Join(tempDir, "PNGs", "transparent.png"),
+		filepath.Join(tempDir, "Pictures", "opaque.png"),
+		filepath.Join(tempDir, "PNGs", "sticker.gif"),
+		filepath.Join(tempDir, "PNGs", "sticker.webp"),
+	}
+	for _, path := range expected {
+		if !helpers.FileExists(path) {
+			t.Errorf("Expected file at %s", path)
+		}
+	}
+	if got := stats.GetTransparentPNGsMoved(); got != 3 {
+		t.Errorf("Expected 3 transparent images moved, got %d", got)
+	}
+}
 
 func TestFileProcessor_TransparentPNGDetectionDisabled(t *testing.T) {
 	tempDir, err := os.MkdirTemp("", "gosorter_test_png_disabled")
@@ -326,3 +306,224 @@ func TestFileProcessor_ZipFileHandling(t *testing.T) {
 		t.Error("Expected at least one file to be processed")
 	}
 }
+
+func TestFileProcessor_ZipFollowsConfiguration(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_zip_config")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	mtime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.Local)
+	for _, name := range []string{"backup.zip", "photos.zip"} {
+		path := filepath.Join(tempDir, name)
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatalf("Failed to set mtime: %v", err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(tempDir, "photos"), 0750); err != nil {
+		t.Fatalf("Failed to create extracted directory: %v", err)
+	}
+
+	config := &model.Config{Silent: true}
+	stats := &model.Stats{StartTime: time.Now()}
+	processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+	processor.extConfig.ExtensionToFolder[".zip"] = "Compressed"
+	processor.extConfig.DateSubfolders = "{yyyy}"
+	processor.extConfig.DestinationTemplates = map[string]model.DestinationTemplate{".zip": {FileName: "{yyyy}-{mm}_{name}"}}
+	if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+		t.Fatalf("ProcessDirectory failed: %v", err)
+	}
+
+	// mapping, date subfolders and file name template apply, only extracted archives go elsewhere
+	for _, path := range []string{"Compressed/2024/2024-03_backup.zip", "Archives-Extracted/photos.zip"} {
+		if !helpers.FileExists(filepath.Join(tempDir, filepath.FromSlash(path))) {
+			t.Errorf("Expected %s", path)
+		}
+	}
+	if helpers.FolderExists(filepath.Join(tempDir, "Archives")) {
+		t.Error("Expected no Archives folder")
+	}
+}
+
+func TestFileProcessor_DuplicateNameTaken(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_duplicate_taken")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	// a duplicate of the same name left by an earlier run
+	testFiles := map[string]string{
+		"a.pdf":                           "same",
+		"b.pdf":                           "same",
+		"Duplicates/b_duplicate_of_a.pdf": "earlier",
+	}
+	for name, content := range testFiles {
+		path := filepath.Join(tempDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatalf("Failed to create folder: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	config := &model.Config{MoveDuplicates: true, Silent: true}
+	stats := &model.Stats{StartTime: time.Now()}
+	processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+	if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+		t.Fatalf("ProcessDirectory failed: %v", err)
+	}
+
+	for path, want := range map[string]string{
+		"Duplicates/b_duplicate_of_a.pdf":    "earlier",
+		"Duplicates/b_duplicate_of_a(1).pdf": "same",
+		"PDFs/a.pdf":                         "same",
+	} {
+		content, err := os.ReadFile(filepath.Join(tempDir, filepath.FromSlash(path)))
+		if err != nil || string(content) != want {
+			t.Errorf("Expected %s to contain %q, got %q (%v)", path, want, content, err)
+		}
+	}
+}
+
+func TestFileProcessor_ConfiguredFolderNames(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_folder_names")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	testFiles := map[string]string{
+		"a.pdf":       "same",
+		"b.pdf":       "same",
+		"archive.zip": "ZIP content",
+	}
+	for name, content := range testFiles {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(tempDir, "archive"), 0750); err != nil {
+		t.Fatalf("Failed to create extracted directory: %v", err)
+	}
+
+	config := &model.Config{MoveDuplicates: true, Silent: true}
+	stats := &model.Stats{StartTime: time.Now()}
+	processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+	processor.extConfig.DuplicatesFolder = "Dupes"
+	processor.extConfig.ArchiveExtractedFolder = "Unpacked"
+	if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+		t.Fatalf("ProcessDirectory failed: %v", err)
+	}
+
+	for _, path := range []string{"Dupes/b_duplicate_of_a.pdf", "Unpacked/archive.zip", "PDFs/a.pdf"} {
+		if !helpers.FileExists(filepath.Join(tempDir, filepath.FromSlash(path))) {
+			t.Errorf("Expected %s", path)
+		}
+	}
+	for _, folder := range []string{"Duplicates", "Archives-Extracted"} {
+		if helpers.FolderExists(filepath.Join(tempDir, folder)) {
+			t.Errorf("Expected no %s folder", folder)
+		}
+	}
+}
+
+func TestDateFromFileName(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   string
+		wantOK bool
+	}{
+		{"2024-03-01_statement.pdf", "2024-03-01", true},
+		{"IMG_20240301_101010.jpg", "2024-03-01", true},
+		{"scan 2023.12.24.pdf", "2023-12-24", true},
+		{"invoice-20240231.pdf", "", false},
+		{"order-120240301.pdf", "", false},
+		{"notes.txt", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := helpers.DateFromFileName(tt.name)
+			if ok != tt.wantOK {
+				t.Fatalf("DateFromFileName(%q) ok = %v, want %v", tt.name, ok, tt.wantOK)
+			}
+			if ok && got.Format("2006-01-02") != tt.want {
+				t.Errorf("DateFromFileName(%q) = %s, want %s", tt.name, got.Format("2006-01-02"), tt.want)
+			}
+		})
+	}
+}
+
+func TestFileProcessor_DateSubfolders(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_dates")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	testFiles := []string{"2024-03-01_statement.pdf", "notes.txt"}
+	for _, file := range testFiles {
+		filePath := filepath.Join(tempDir, file)
+		if err := os.WriteFile(filePath, []byte(file), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", file, err)
+		}
+	}
+	mtime := time.Date(2022, 11, 5, 12, 0, 0, 0, time.Local)
+	if err := os.Chtimes(filepath.Join(tempDir, "notes.txt"), mtime, mtime); err != nil {
+		t.Fatalf("Failed to set mtime: %v", err)
+	}
+
+	config := &model.Config{Silent: true}
+	stats := &model.Stats{StartTime: time.Now()}
+	processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+	processor.extConfig.ExtensionToFolder[".pdf"] = "PDFs/{yyyy}/{mm}"
+	processor.extConfig.DateSource = model.DateSourceFilename
+
+	if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+		t.Fatalf("ProcessDirectory with date subfolders failed: %v", err)
+	}
+
+	expected := []string{
+		filepath.Join(tempDir, "PDFs", "2024", "03", "2024-03-01_statement.pdf"),
+		filepath.Join(tempDir, "Documents", "notes.txt"),
+	}
+	for _, path := range expected {
+		if !helpers.FileExists(path) {
+			t.Errorf("Expected file at %s", path)
+		}
+	}
+
+	// global setting, falls back to mtime for names without a date
+	if err := os.Rename(filepath.Join(tempDir, "Documents", "notes.txt"), filepath.Join(tempDir, "notes.txt")); err != nil {
+		t.Fatalf("Failed to reset test file: %v", err)
+	}
+	processor.extConfig.DateSubfolders = "{yyyy}"
+	if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+		t.Fatalf("ProcessDirectory with global date subfolders failed: %v", err)
+	}
+	if !helpers.FileExists(filepath.Join(tempDir, "Documents", "2022", "notes.txt")) {
+		t.Error("Expected notes.txt to be moved to Documents/2022")
+	}
+}
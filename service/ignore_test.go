@@ -0,0 +1,76 @@
+// Package service - ignore file tests
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mohamedation/GoSorter/helpers"
+	"github.com/mohamedation/GoSorter/model"
+)
+
+func TestFileProcessor_IgnoreFile(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		config model.Config
+	}{
+		{"streamed", model.Config{Silent: true}},
+		{"duplicates", model.Config{Silent: true, MoveDuplicates: true}},
+		{"copies", model.Config{Silent: true, ConsolidateCopies: true}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tempDir, err := os.MkdirTemp("", "gosorter_test_ignore")
+			if err != nil {
+				t.Fatalf("Failed to create temp dir: %v", err)
+			}
+			defer func() {
+				if err := os.RemoveAll(tempDir); err != nil {
+					t.Fatalf("Failed to remove temp dir: %v", err)
+				}
+			}()
+
+			ignore := "# never move these\n*.pdf\n!invoice.pdf\n/todo.txt\nmovie.srt\n"
+			if err := os.WriteFile(filepath.Join(tempDir, model.IgnoreFileName), []byte(ignore), 0644); err != nil {
+				t.Fatalf("Failed to write ignore file: %v", err)
+			}
+			files := map[string]string{
+				"report.pdf":     "report",
+				"report (1).pdf": "report",
+				"invoice.pdf":    "invoice",
+				"todo.txt":       "todo",
+				"notes.txt":      "notes",
+				"movie.mkv":      "movie",
+				"movie.srt":      "subtitles",
+			}
+			for name, content := range files {
+				if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+					t.Fatalf("Failed to create test file: %v", err)
+				}
+			}
+
+			config := tc.config
+			stats := &model.Stats{StartTime: time.Now()}
+			processor := NewFileProcessor(&config, stats, &helpers.CLILogger{})
+			if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			for _, name := range []string{model.IgnoreFileName, "report.pdf", "report (1).pdf", "todo.txt", "movie.srt"} {
+				if !helpers.FileExists(filepath.Join(tempDir, name)) {
+					t.Errorf("Expected %s to stay in place", name)
+				}
+			}
+			for _, path := range []string{"PDFs/invoice.pdf", "Documents/notes.txt", "Videos/movie.mkv"} {
+				if !helpers.FileExists(filepath.Join(tempDir, filepath.FromSlash(path))) {
+					t.Errorf("Expected %s", path)
+				}
+			}
+			if got := stats.GetIgnored(); got != 4 {
+				t.Errorf("Expected 4 ignored files, got %d", got)
+			}
+		})
+	}
+}
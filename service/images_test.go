@@ -0,0 +1,137 @@
+// Package service - transparency detection tests
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mohamedation/GoSorter/helpers"
+	"github.com/mohamedation/GoSorter/model"
+)
+
+// buildNRGBAPNG - 10x10 image with the first seeThrough pixels fully transparent
+func buildNRGBAPNG(t *testing.T, seeThrough int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+	for i := range 100 {
+		a := uint8(0xff)
+		if i < seeThrough {
+			a = 0
+		}
+		img.SetNRGBA(i%10, i/10, color.NRGBA{R: 0x80, A: a})
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func buildTransparentGIF(t *testing.T) []byte {
+	img := image.NewPaletted(image.Rect(0, 0, 10, 10), color.Palette{color.Transparent, color.Black})
+	var buf bytes.Buffer
+	if err := gif.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("Failed to encode GIF: %v", err)
+	}
+	return buf.Bytes()
+}
+
+type bitWriter struct {
+	buf   []byte
+	nbits uint
+}
+
+// write - n bits of v, least significant first like VP8L expects
+func (w *bitWriter) write(v uint32, n uint) {
+	for i := range n {
+		if w.nbits%8 == 0 {
+			w.buf = append(w.buf, 0)
+		}
+		if v&(1<<i) != 0 {
+			w.buf[len(w.buf)-1] |= 1 << (w.nbits % 8)
+		}
+		w.nbits++
+	}
+}
+
+// buildWebPLossless - 1x1 VP8L image, every prefix code is a single 8 bit symbol
+func buildWebPLossless(alpha uint8) []byte {
+	w := &bitWriter{}
+	w.write(0x2f, 8)                                             // signature
+	w.write(0, 14)                                               // width - 1
+	w.write(0, 14)                                               // height - 1
+	w.write(1, 1)                                                // alpha_is_used
+	w.write(0, 3)                                                // version
+	w.write(0, 1)                                                // no transforms
+	w.write(0, 1)                                                // no color cache
+	w.write(0, 1)                                                // no meta prefix codes
+	for _, symbol := range []uint32{0, 0, 0, uint32(alpha), 0} { // green, red, blue, alpha, distance
+		w.write(1, 1) // simple code
+		w.write(0, 1) // one symbol
+		w.write(1, 1) // 8 bit symbol
+		w.write(symbol, 8)
+	}
+	data := w.buf
+	chunk := binary.LittleEndian.AppendUint32([]byte("VP8L"), uint32(len(data)))
+	chunk = append(chunk, data...)
+	if len(data)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	out := binary.LittleEndian.AppendUint32([]byte("RIFF"), uint32(4+len(chunk)))
+	return append(append(out, "WEBP"...), chunk...)
+}
+
+func TestHasTransparency(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_transparency")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	// lossy VP8 never has alpha, answered from the header alone
+	lossyWebP := append([]byte("RIFF\x16\x00\x00\x00WEBPVP8 \x0a\x00\x00\x00"), make([]byte, 10)...)
+
+	tests := []struct {
+		file      string
+		data      []byte
+		threshold float64
+		want      bool
+	}{
+		{"transparent.png", buildNRGBAPNG(t, 100), 0, true},
+		{"opaque.png", buildNRGBAPNG(t, 0), 0, false},
+		{"gray.png", encodePNG(t, 10, 10), 0, false},
+		{"partly.png", buildNRGBAPNG(t, 30), 0, false},
+		{"partly-low-threshold.png", buildNRGBAPNG(t, 30), 0.2, true},
+		{"exactly-threshold.png", buildNRGBAPNG(t, 50), 0.5, false},
+		{"transparent.gif", buildTransparentGIF(t), 0, true},
+		{"transparent.webp", buildWebPLossless(0), 0, true},
+		{"opaque.webp", buildWebPLossless(0xff), 0, false},
+		{"lossy.webp", lossyWebP, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			filePath := filepath.Join(tempDir, tt.file)
+			if err := os.WriteFile(filePath, tt.data, 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+			cfg := model.Config{Silent: true, TransparencyThreshold: tt.threshold}
+			got, err := helpers.HasTransparency(filePath, cfg, &helpers.CLILogger{})
+			if err != nil {
+				t.Fatalf("HasTransparency failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("HasTransparency() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
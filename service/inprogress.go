@@ -0,0 +1,56 @@
+// Package service - files still being written
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mohamedation/GoSorter/helpers"
+)
+
+// partialDownloadExts - browsers and torrent clients rename these once the download is complete
+var partialDownloadExts = map[string]bool{
+	".part":       true, // Firefox, wget
+	".crdownload": true, // Chrome, Edge
+	".download":   true, // Safari
+	".!qb":        true, // qBittorrent
+}
+
+// writeGuard - recognizes files another program is still writing: partial downloads, files
+// modified within the quiet period and, on Linux, files open for writing by any process
+type writeGuard struct {
+	folder string
+	quiet  time.Duration
+	open   map[string]bool // taken once when the run starts
+}
+
+func (fp *FileProcessor) newWriteGuard(folderPath string) *writeGuard {
+	open, err := helpers.OpenForWriting(folderPath)
+	if err != nil {
+		fp.Logger.Log(*fp.config, helpers.Debug, fmt.Sprintf("Cannot check for open files: %v\n", err))
+	}
+	return &writeGuard{folder: folderPath, quiet: fp.config.QuietPeriod, open: open}
+}
+
+// busy - why the file should be left alone, empty when it can be moved
+func (g *writeGuard) busy(entry os.DirEntry) string {
+	if entry.IsDir() {
+		return ""
+	}
+	name := entry.Name()
+	if partialDownloadExts[strings.ToLower(filepath.Ext(name))] {
+		return "is a partial download"
+	}
+	if g.open[name] {
+		return "is open for writing"
+	}
+	if g.quiet > 0 {
+		if info, err := entryInfo(g.folder, entry); err == nil && time.Since(info.ModTime()) < g.quiet {
+			return fmt.Sprintf("was modified less than %s ago", g.quiet)
+		}
+	}
+	return ""
+}
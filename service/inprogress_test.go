@@ -0,0 +1,90 @@
+// Package service - in-progress file tests
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/mohamedation/GoSorter/helpers"
+	"github.com/mohamedation/GoSorter/model"
+)
+
+func TestFileProcessor_SkipsFilesInProgress(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		config model.Config
+	}{
+		{"streamed", model.Config{Silent: true, QuietPeriod: time.Hour}},
+		{"duplicates", model.Config{Silent: true, QuietPeriod: time.Hour, MoveDuplicates: true}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tempDir, err := os.MkdirTemp("", "gosorter_test_in_progress")
+			if err != nil {
+				t.Fatalf("Failed to create temp dir: %v", err)
+			}
+			defer func() {
+				if err := os.RemoveAll(tempDir); err != nil {
+					t.Fatalf("Failed to remove temp dir: %v", err)
+				}
+			}()
+
+			old := time.Now().Add(-2 * time.Hour)
+			files := map[string]time.Time{
+				"done.pdf":             old,
+				"fresh.pdf":            time.Now(),
+				"movie.mkv.part":       old,
+				"setup.exe.crdownload": old,
+				"album.zip.download":   old,
+				"ubuntu.iso.!qB":       old,
+				"writing.pdf":          old,
+				"part":                 old, // no extension, not a partial download
+			}
+			for name, modTime := range files {
+				path := filepath.Join(tempDir, name)
+				if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+					t.Fatalf("Failed to create test file: %v", err)
+				}
+				if err := os.Chtimes(path, modTime, modTime); err != nil {
+					t.Fatalf("Failed to set file time: %v", err)
+				}
+			}
+			// an open write handle, like a download in progress
+			writer, err := os.OpenFile(filepath.Join(tempDir, "writing.pdf"), os.O_WRONLY|os.O_APPEND, 0644)
+			if err != nil {
+				t.Fatalf("Failed to open test file: %v", err)
+			}
+			defer func() {
+				if err := writer.Close(); err != nil {
+					t.Fatalf("Failed to close test file: %v", err)
+				}
+			}()
+
+			config := tc.config
+			stats := &model.Stats{StartTime: time.Now()}
+			processor := NewFileProcessor(&config, stats, &helpers.CLILogger{})
+			if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			if !helpers.FileExists(filepath.Join(tempDir, "PDFs", "done.pdf")) {
+				t.Error("Expected done.pdf to be sorted")
+			}
+			stay := []string{"fresh.pdf", "movie.mkv.part", "setup.exe.crdownload", "album.zip.download", "ubuntu.iso.!qB"}
+			if runtime.GOOS == "linux" {
+				stay = append(stay, "writing.pdf")
+			}
+			for _, name := range stay {
+				if !helpers.FileExists(filepath.Join(tempDir, name)) {
+					t.Errorf("Expected %s to stay in place", name)
+				}
+			}
+			if got := stats.GetInProgressSkipped(); got != int64(len(stay)) {
+				t.Errorf("Expected %d files skipped as in progress, got %d", len(stay), got)
+			}
+		})
+	}
+}
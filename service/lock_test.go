@@ -0,0 +1,75 @@
+// Package service - directory lock tests
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mohamedation/GoSorter/helpers"
+	"github.com/mohamedation/GoSorter/model"
+)
+
+func TestFileProcessor_DirectoryLock(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_lock")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+	if err := os.WriteFile(filepath.Join(tempDir, "report.pdf"), []byte("report"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	config := &model.Config{Silent: true}
+	lock, err := helpers.LockDirectory(tempDir, *config, &helpers.CLILogger{})
+	if err != nil {
+		t.Fatalf("LockDirectory failed: %v", err)
+	}
+
+	// another run is sorting the directory
+	processor := NewFileProcessor(config, &model.Stats{StartTime: time.Now()}, &helpers.CLILogger{})
+	err = processor.ProcessDirectory(context.Background(), tempDir)
+	var locked *helpers.LockedError
+	if !errors.As(err, &locked) {
+		t.Fatalf("Expected a LockedError, got %v", err)
+	}
+	if locked.Holder.PID != os.Getpid() {
+		t.Errorf("Expected the holder pid %d, got %d", os.Getpid(), locked.Holder.PID)
+	}
+	if !helpers.FileExists(filepath.Join(tempDir, "report.pdf")) {
+		t.Error("Expected no files to be moved while the directory is locked")
+	}
+	if err := lock.Unlock(); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+
+	// a run that crashed left its lock file behind
+	lockFile := filepath.Join(tempDir, helpers.LockFileName)
+	host, _ := os.Hostname()
+	stale := `{"pid": 2147483647, "host": "` + host + `", "started": "2024-01-01T00:00:00Z"}`
+	if err := os.WriteFile(lockFile, []byte(stale), 0644); err != nil {
+		t.Fatalf("Failed to create stale lock file: %v", err)
+	}
+
+	stats := &model.Stats{StartTime: time.Now()}
+	processor = NewFileProcessor(config, stats, &helpers.CLILogger{})
+	if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+		t.Fatalf("Expected the stale lock to be taken over, got %v", err)
+	}
+	if !helpers.FileExists(filepath.Join(tempDir, "PDFs", "report.pdf")) {
+		t.Error("Expected report.pdf to be sorted")
+	}
+	if helpers.FileExists(lockFile) {
+		t.Error("Expected the lock file to be removed after the run")
+	}
+	if got := stats.GetTotalFiles(); got != 1 {
+		t.Errorf("Expected the lock file not to be processed, got %d files", got)
+	}
+}
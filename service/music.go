@@ -0,0 +1,88 @@
+// Package service - music organization
+package service
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/mohamedation/GoSorter/helpers"
+	"github.com/mohamedation/GoSorter/model"
+)
+
+// audio extensions, the ones mapped to Music by default
+var musicExtensions = map[string]bool{
+	".mp3":  true,
+	".wav":  true,
+	".flac": true,
+	".aac":  true,
+	".ogg":  true,
+	".m4a":  true,
+	".wma":  true,
+	".opus": true,
+	".m4b":  true,
+	".m4p":  true,
+}
+
+// extensions with tags we can read
+var audioTagExtensions = map[string]bool{
+	".mp3":  true,
+	".flac": true,
+	".m4a":  true,
+	".m4b":  true,
+	".m4p":  true,
+}
+
+const (
+	unknownArtist = "Unknown Artist"
+	unknownAlbum  = "Unknown Album"
+)
+
+// musicValues - artist/album/title/track with fallbacks, shared by the music layout and templates
+func (fp *FileProcessor) musicValues(file model.FileDetail) map[string]string {
+	tags := &helpers.AudioTags{}
+	if audioTagExtensions[file.Ext] {
+		if t, err := helpers.ReadAudioTags(file.Path); err == nil {
+			tags = t
+		} else {
+			fp.Logger.Log(*fp.config, helpers.Debug, fmt.Sprintf("No audio tags in %s: %v\n", file.Name, err))
+		}
+	}
+
+	artist := tags.AlbumArtist
+	if artist == "" {
+		artist = tags.Artist
+	}
+	values := map[string]string{
+		"artist": helpers.SanitizeName(artist),
+		"album":  helpers.SanitizeName(tags.Album),
+		"title":  helpers.SanitizeName(tags.Title),
+		"track":  "",
+	}
+	if values["artist"] == "" {
+		values["artist"] = unknownArtist
+	}
+	if values["album"] == "" {
+		values["album"] = unknownAlbum
+	}
+	if tags.Track > 0 {
+		values["track"] = fmt.Sprintf("%02d", tags.Track)
+	}
+	return values
+}
+
+// musicDestination - applies the configured music layout, renames from tags when there is a title
+func (fp *FileProcessor) musicDestination(file model.FileDetail, targetFolder, newName string) (string, string) {
+	if fp.extConfig.MusicLayout != model.MusicLayoutArtistAlbum {
+		return targetFolder, newName
+	}
+
+	values := fp.musicValues(file)
+	targetFolder = filepath.Join(targetFolder, values["artist"], values["album"])
+	if values["title"] != "" {
+		newName = values["title"] + filepath.Ext(file.Name)
+		if values["track"] != "" {
+			newName = values["track"] + " - " + newName
+		}
+	}
+	return targetFolder, newName
+}
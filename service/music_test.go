@@ -0,0 +1,170 @@
+// Package service - music organization tests
+package service
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mohamedation/GoSorter/helpers"
+	"github.com/mohamedation/GoSorter/model"
+)
+
+// buildID3v23 - ID3v2.3 tag with latin1 text frames followed by fake audio
+func buildID3v23(frames map[string]string) []byte {
+	var body []byte
+	for _, id := range []string{"TPE1", "TPE2", "TALB", "TIT2", "TRCK"} {
+		text, ok := frames[id]
+		if !ok {
+			continue
+		}
+		frame := make([]byte, 10)
+		copy(frame, id)
+		binary.BigEndian.PutUint32(frame[4:], uint32(len(text)+1))
+		frame = append(frame, 0) // ISO-8859-1
+		body = append(body, append(frame, text...)...)
+	}
+	size := len(body)
+	header := []byte{'I', 'D', '3', 3, 0, 0,
+		byte(size >> 21 & 0x7F), byte(size >> 14 & 0x7F), byte(size >> 7 & 0x7F), byte(size & 0x7F)}
+	return append(append(header, body...), 0xFF, 0xFB, 0x90, 0x00)
+}
+
+// buildFLAC - STREAMINFO-less FLAC with just a vorbis comment block
+func buildFLAC(comments ...string) []byte {
+	le := binary.LittleEndian
+	vendor := "test"
+	block := le.AppendUint32(nil, uint32(len(vendor)))
+	block = append(block, vendor...)
+	block = le.AppendUint32(block, uint32(len(comments)))
+	for _, c := range comments {
+		block = le.AppendUint32(block, uint32(len(c)))
+		block = append(block, c...)
+	}
+	out := []byte("fLaC")
+	out = append(out, 0x80|4, byte(len(block)>>16), byte(len(block)>>8), byte(len(block)))
+	return append(out, block...)
+}
+
+func mp4Box(boxType string, payload ...[]byte) []byte {
+	size := 8
+	for _, p := range payload {
+		size += len(p)
+	}
+	out := binary.BigEndian.AppendUint32(nil, uint32(size))
+	out = append(out, boxType...)
+	for _, p := range payload {
+		out = append(out, p...)
+	}
+	return out
+}
+
+// buildM4A - ftyp + moov/udta/meta/ilst with artist, album, title and track
+func buildM4A(artist, album, title string, track uint16) []byte {
+	item := func(name string, value []byte) []byte {
+		return mp4Box(name, mp4Box("data", []byte{0, 0, 0, 1, 0, 0, 0, 0}, value))
+	}
+	trkn := []byte{0, 0, byte(track >> 8), byte(track), 0, 0, 0, 0}
+	ilst := mp4Box("ilst",
+		item("\xa9ART", []byte(artist)),
+		item("\xa9alb", []byte(album)),
+		item("\xa9nam", []byte(title)),
+		item("trkn", trkn),
+	)
+	meta := mp4Box("meta", []byte{0, 0, 0, 0}, ilst)
+	return append(mp4Box("ftyp", []byte("M4A \x00\x00\x00\x00")), mp4Box("moov", mp4Box("udta", meta))...)
+}
+
+func TestReadAudioTags(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_tags")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	tests := []struct {
+		file string
+		data []byte
+		want helpers.AudioTags
+	}{
+		{
+			file: "song.mp3",
+			data: buildID3v23(map[string]string{"TPE1": "Artist", "TPE2": "Band", "TALB": "Album", "TIT2": "Song", "TRCK": "3/12"}),
+			want: helpers.AudioTags{Artist: "Artist", AlbumArtist: "Band", Album: "Album", Title: "Song", Track: 3},
+		},
+		{
+			file: "song.flac",
+			data: buildFLAC("ARTIST=Flac Artist", "ALBUM=Lossless", "TITLE=Intro", "TRACKNUMBER=1"),
+			want: helpers.AudioTags{Artist: "Flac Artist", Album: "Lossless", Title: "Intro", Track: 1},
+		},
+		{
+			file: "song.m4a",
+			data: buildM4A("M4A Artist", "AAC Album", "Outro", 12),
+			want: helpers.AudioTags{Artist: "M4A Artist", Album: "AAC Album", Title: "Outro", Track: 12},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			filePath := filepath.Join(tempDir, tt.file)
+			if err := os.WriteFile(filePath, tt.data, 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+			got, err := helpers.ReadAudioTags(filePath)
+			if err != nil {
+				t.Fatalf("ReadAudioTags failed: %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("ReadAudioTags() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileProcessor_MusicLayout(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_music")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	testFiles := map[string][]byte{
+		"track03.mp3":  buildID3v23(map[string]string{"TPE1": "AC/DC", "TALB": "Back in Black", "TIT2": "Hells Bells", "TRCK": "1"}),
+		"untagged.mp3": {0xFF, 0xFB, 0x90, 0x00},
+	}
+	for file, data := range testFiles {
+		if err := os.WriteFile(filepath.Join(tempDir, file), data, 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", file, err)
+		}
+	}
+
+	config := &model.Config{Silent: true}
+	stats := &model.Stats{StartTime: time.Now()}
+	processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+	processor.extConfig.MusicLayout = model.MusicLayoutArtistAlbum
+
+	if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+		t.Fatalf("ProcessDirectory with music layout failed: %v", err)
+	}
+
+	expected := []string{
+		filepath.Join(tempDir, "Music", "AC_DC", "Back in Black", "01 - Hells Bells.mp3"),
+		filepath.Join(tempDir, "Music", "Unknown Artist", "Unknown Album", "untagged.mp3"),
+	}
+	for _, path := range expected {
+		if !helpers.FileExists(path) {
+			t.Errorf("Expected file at %s", path)
+		}
+	}
+}
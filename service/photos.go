@@ -0,0 +1,71 @@
+// Package service - photo organization
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mohamedation/GoSorter/helpers"
+	"github.com/mohamedation/GoSorter/model"
+)
+
+// extensions that can carry EXIF
+var exifExtensions = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".heic": true,
+	".heif": true,
+	".tif":  true,
+	".tiff": true,
+}
+
+const unknownCamera = "Unknown Camera"
+
+// photoTargetFolder - applies the configured photo layout below the photo's target folder
+func (fp *FileProcessor) photoTargetFolder(file model.FileDetail, targetFolder string) string {
+	layout := fp.extConfig.PhotoLayout
+	if layout == "" || layout == model.PhotoLayoutFlat {
+		return targetFolder
+	}
+
+	exif, err := helpers.ReadExif(file.Path)
+	if err != nil {
+		fp.Logger.Log(*fp.config, helpers.Debug, fmt.Sprintf("No EXIF in %s: %v\n", file.Name, err))
+		exif = &helpers.ExifData{}
+	}
+
+	taken := exif.DateTimeOriginal
+	if taken.IsZero() {
+		taken = fileModTime(file.Path)
+	}
+	camera := exif.Model
+	if camera == "" {
+		camera = exif.Make
+	}
+	camera = helpers.SanitizeName(camera)
+	if camera == "" {
+		camera = unknownCamera
+	}
+	dateFolder := filepath.Join(taken.Format("2006"), taken.Format("2006-01"))
+
+	switch layout {
+	case model.PhotoLayoutDate:
+		return filepath.Join(targetFolder, dateFolder)
+	case model.PhotoLayoutCamera:
+		return filepath.Join(targetFolder, camera)
+	case model.PhotoLayoutCameraDate:
+		return filepath.Join(targetFolder, camera, dateFolder)
+	}
+	fp.Logger.Log(*fp.config, helpers.Debug, fmt.Sprintf("Unknown photo layout %q, using flat layout\n", layout))
+	return targetFolder
+}
+
+func fileModTime(filePath string) time.Time {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return time.Now()
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,160 @@
+// Package service - photo organization tests
+package service
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mohamedation/GoSorter/helpers"
+	"github.com/mohamedation/GoSorter/model"
+)
+
+// buildExifTIFF - little endian TIFF with Make/Model in IFD0 and DateTimeOriginal in the Exif IFD
+func buildExifTIFF(cameraMake, cameraModel, dateTimeOriginal string) []byte {
+	le := binary.LittleEndian
+	ascii := func(s string) []byte { return append([]byte(s), 0) }
+	makeVal, modelVal, dateVal := ascii(cameraMake), ascii(cameraModel), ascii(dateTimeOriginal)
+
+	ifd0 := 8
+	ifd0Size := 2 + 3*12 + 4
+	exifIFD := ifd0 + ifd0Size
+	exifIFDSize := 2 + 1*12 + 4
+	dataStart := exifIFD + exifIFDSize
+
+	buf := make([]byte, dataStart)
+	copy(buf, "II")
+	le.PutUint16(buf[2:], 42)
+	le.PutUint32(buf[4:], uint32(ifd0))
+
+	appendData := func(v []byte) uint32 {
+		// values up to 4 bytes live in the entry itself
+		if len(v) <= 4 {
+			inline := make([]byte, 4)
+			copy(inline, v)
+			return le.Uint32(inline)
+		}
+		off := uint32(len(buf))
+		buf = append(buf, v...)
+		return off
+	}
+	entry := func(at int, tag, typ uint16, count, value uint32) {
+		le.PutUint16(buf[at:], tag)
+		le.PutUint16(buf[at+2:], typ)
+		le.PutUint32(buf[at+4:], count)
+		le.PutUint32(buf[at+8:], value)
+	}
+
+	le.PutUint16(buf[ifd0:], 3)
+	entry(ifd0+2, 0x010F, 2, uint32(len(makeVal)), appendData(makeVal))
+	entry(ifd0+14, 0x0110, 2, uint32(len(modelVal)), appendData(modelVal))
+	entry(ifd0+26, 0x8769, 4, 1, uint32(exifIFD))
+
+	le.PutUint16(buf[exifIFD:], 1)
+	entry(exifIFD+2, 0x9003, 2, uint32(len(dateVal)), appendData(dateVal))
+	return buf
+}
+
+// buildExifJPEG - minimal JPEG container around an APP1 Exif segment
+func buildExifJPEG(tiff []byte) []byte {
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	out := []byte{0xFF, 0xD8, 0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(out[4:], uint16(len(payload)+2))
+	out = append(out, payload...)
+	return append(out, 0xFF, 0xD9)
+}
+
+func TestReadExif(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_exif")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	jpgPath := filepath.Join(tempDir, "photo.jpg")
+	if err := os.WriteFile(jpgPath, buildExifJPEG(buildExifTIFF("Canon", "Canon EOS R6", "2024:07:15 10:30:00")), 0644); err != nil {
+		t.Fatalf("Failed to create test JPEG: %v", err)
+	}
+	tiffPath := filepath.Join(tempDir, "scan.tiff")
+	if err := os.WriteFile(tiffPath, buildExifTIFF("Nikon", "Z 6", "2023:01:02 03:04:05"), 0644); err != nil {
+		t.Fatalf("Failed to create test TIFF: %v", err)
+	}
+
+	exif, err := helpers.ReadExif(jpgPath)
+	if err != nil {
+		t.Fatalf("ReadExif(jpg) failed: %v", err)
+	}
+	if exif.Make != "Canon" || exif.Model != "Canon EOS R6" {
+		t.Errorf("Expected Canon / Canon EOS R6, got %q / %q", exif.Make, exif.Model)
+	}
+	if got := exif.DateTimeOriginal.Format("2006-01-02 15:04:05"); got != "2024-07-15 10:30:00" {
+		t.Errorf("Expected DateTimeOriginal 2024-07-15 10:30:00, got %s", got)
+	}
+
+	exif, err = helpers.ReadExif(tiffPath)
+	if err != nil {
+		t.Fatalf("ReadExif(tiff) failed: %v", err)
+	}
+	if exif.Model != "Z 6" {
+		t.Errorf("Expected model Z 6, got %q", exif.Model)
+	}
+
+	noExifPath := filepath.Join(tempDir, "plain.jpg")
+	if err := os.WriteFile(noExifPath, []byte{0xFF, 0xD8, 0xFF, 0xD9}, 0644); err != nil {
+		t.Fatalf("Failed to create test JPEG: %v", err)
+	}
+	if _, err := helpers.ReadExif(noExifPath); err == nil {
+		t.Error("Expected an error for a JPEG without EXIF")
+	}
+}
+
+func TestFileProcessor_PhotoLayout(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_photos")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	withExif := buildExifJPEG(buildExifTIFF("Apple", "iPhone 15 Pro", "2024:07:15 10:30:00"))
+	if err := os.WriteFile(filepath.Join(tempDir, "IMG_0001.jpg"), withExif, 0644); err != nil {
+		t.Fatalf("Failed to create test JPEG: %v", err)
+	}
+	noExifPath := filepath.Join(tempDir, "download.jpg")
+	if err := os.WriteFile(noExifPath, []byte{0xFF, 0xD8, 0xFF, 0xD9}, 0644); err != nil {
+		t.Fatalf("Failed to create test JPEG: %v", err)
+	}
+	mtime := time.Date(2021, 3, 4, 12, 0, 0, 0, time.Local)
+	if err := os.Chtimes(noExifPath, mtime, mtime); err != nil {
+		t.Fatalf("Failed to set mtime: %v", err)
+	}
+
+	config := &model.Config{Silent: true}
+	stats := &model.Stats{StartTime: time.Now()}
+	processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+	processor.extConfig.PhotoLayout = model.PhotoLayoutCameraDate
+
+	if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+		t.Fatalf("ProcessDirectory with photo layout failed: %v", err)
+	}
+
+	expected := []string{
+		filepath.Join(tempDir, "Pictures", "iPhone 15 Pro", "2024", "2024-07", "IMG_0001.jpg"),
+		filepath.Join(tempDir, "Pictures", "Unknown Camera", "2021", "2021-03", "download.jpg"),
+	}
+	for _, path := range expected {
+		if !helpers.FileExists(path) {
+			t.Errorf("Expected file at %s", path)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// Package service - sorting pipeline
+package service
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	"github.com/mohamedation/GoSorter/helpers"
+	"github.com/mohamedation/GoSorter/model"
+)
+
+type classified struct {
+	index     int
+	placement placement
+	ok        bool // false for skipped files (unknown extension, classify errors, cancelled)
+}
+
+// workerCounts - classify and move workers, zero values fall back to the defaults
+func (fp *FileProcessor) workerCounts() (int, int) {
+	classifyWorkers := fp.config.ClassifyWorkers
+	if classifyWorkers <= 0 {
+		classifyWorkers = model.DefaultWorkerCount
+	}
+	moveWorkers := fp.config.MoveWorkers
+	if moveWorkers <= 0 {
+		moveWorkers = 1
+	}
+	return classifyWorkers, moveWorkers
+}
+
+// sortFiles - classify and move stages for the files coming out of the scan stage.
+// files are classified (metadata, transparency, screenshots) in parallel and handed to the
+// move stage in scan order. moves into the same folder always go through the same move
+// worker, so conflict renames ("name(1).ext") do not depend on timing. the caller closes files.
+func (fp *FileProcessor) sortFiles(ctx context.Context, folderPath string, files <-chan model.FileDetail) error {
+	classifyWorkers, moveWorkers := fp.workerCounts()
+
+	type job struct {
+		index int
+		file  model.FileDetail
+	}
+	jobs := make(chan job, model.DefaultBufferSize)
+	results := make(chan classified, model.DefaultBufferSize)
+	// files between numbering and the move stage, bounds the reorder buffer
+	window := make(chan struct{}, model.DefaultBufferSize*classifyWorkers)
+
+	go func() {
+		defer close(jobs)
+		index := 0
+		for file := range files {
+			select {
+			case window <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			jobs <- job{index: index, file: file}
+			index++
+		}
+	}()
+
+	var classifyWG sync.WaitGroup
+	for range classifyWorkers {
+		classifyWG.Add(1)
+		go func() {
+			defer classifyWG.Done()
+			for j := range jobs {
+				res := classified{index: j.index}
+				if ctx.Err() == nil {
+					res.placement, res.ok = fp.classifyFile(ctx, folderPath, j.file)
+				}
+				results <- res
+			}
+		}()
+	}
+	go func() {
+		classifyWG.Wait()
+		close(results)
+	}()
+
+	queues := make([]chan placement, moveWorkers)
+	var moveWG sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan placement, model.DefaultBufferSize)
+		moveWG.Add(1)
+		go func(queue <-chan placement) {
+			defer moveWG.Done()
+			for p := range queue {
+				if ctx.Err() != nil {
+					continue
+				}
+				if fp.place(ctx, folderPath, p) {
+					fp.stats.IncrementFilesMoved()
+				}
+			}
+		}(queues[i])
+	}
+
+	// sequencer, releases classified files in scan order
+	pending := make(map[int]classified)
+	next := 0
+	for res := range results {
+		pending[res.index] = res
+		for {
+			r, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			<-window
+			if r.ok {
+				queues[moveShard(r.placement.folder(), moveWorkers)] <- r.placement
+			}
+		}
+	}
+	for _, queue := range queues {
+		close(queue)
+	}
+	moveWG.Wait()
+	return ctx.Err()
+}
+
+// classifyFile - classify for the pipeline, unknown extensions are skipped without counting an error
+func (fp *FileProcessor) classifyFile(ctx context.Context, folderPath string, file model.FileDetail) (placement, bool) {
+	if _, ok := fp.extConfig.ExtensionToFolder[file.Ext]; !ok {
+		fp.stats.IncrementUnknownExtensions(file.Ext)
+		fp.Logger.Log(*fp.config, helpers.Debug, fmt.Sprintf("Skipping file with unknown extension: %s (%s)\n", file.Name, file.Ext))
+		return placement{}, false
+	}
+	p, err := fp.classify(ctx, folderPath, file, fp.extConfig)
+	if err != nil {
+		fp.stats.IncrementErrors()
+		fp.Logger.Log(*fp.config, helpers.Error, fmt.Sprintf("Failed to move file: %v\n", err))
+		return placement{}, false
+	}
+	return p, true
+}
+
+func moveShard(folder string, shards int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(folder))
+	return int(h.Sum32() % uint32(shards))
+}
@@ -0,0 +1,203 @@
+// Package service - sorting pipeline tests
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mohamedation/GoSorter/helpers"
+	"github.com/mohamedation/GoSorter/model"
+)
+
+func TestFileProcessor_PipelineDeterministicConflicts(t *testing.T) {
+	for _, workers := range []struct{ classify, move int }{{1, 1}, {8, 1}, {8, 4}} {
+		t.Run(fmt.Sprintf("%d-classify-%d-move", workers.classify, workers.move), func(t *testing.T) {
+			tempDir, err := os.MkdirTemp("", "gosorter_test_pipeline")
+			if err != nil {
+				t.Fatalf("Failed to create temp dir: %v", err)
+			}
+			defer func() {
+				if err := os.RemoveAll(tempDir); err != nil {
+					t.Fatalf("Failed to remove temp dir: %v", err)
+				}
+			}()
+
+			// every .txt is renamed to "note.txt", conflicts must be numbered in scan (directory) order
+			const count = 50
+			for i := range count {
+				name := fmt.Sprintf("file%03d.txt", i)
+				if err := os.WriteFile(filepath.Join(tempDir, name), []byte(name), 0644); err != nil {
+					t.Fatalf("Failed to create test file: %v", err)
+				}
+				other := fmt.Sprintf("other%03d.csv", i)
+				if err := os.WriteFile(filepath.Join(tempDir, other), []byte(other), 0644); err != nil {
+					t.Fatalf("Failed to create test file: %v", err)
+				}
+			}
+
+			scanOrder := txtScanOrder(t, tempDir)
+
+			config := &model.Config{Silent: true, ClassifyWorkers: workers.classify, MoveWorkers: workers.move}
+			stats := &model.Stats{StartTime: time.Now()}
+			processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+			processor.extConfig.DestinationTemplates = map[string]model.DestinationTemplate{
+				".txt": {FileName: "note"},
+			}
+
+			if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			for i := range count {
+				name := "note.txt"
+				if i > 0 {
+					name = fmt.Sprintf("note(%d).txt", i)
+				}
+				content, err := os.ReadFile(filepath.Join(tempDir, "Documents", name))
+				if err != nil {
+					t.Fatalf("Expected %s: %v", name, err)
+				}
+				if want := scanOrder[i]; string(content) != want {
+					t.Errorf("%s holds %s, want %s", name, content, want)
+				}
+			}
+			if got := stats.GetFilesMoved(); got != 2*count {
+				t.Errorf("Expected %d files moved, got %d", 2*count, got)
+			}
+		})
+	}
+}
+
+// txtScanOrder - .txt names in the order the directory lists them
+func txtScanOrder(t *testing.T, dir string) []string {
+	t.Helper()
+	d, err := os.Open(dir)
+	if err != nil {
+		t.Fatalf("Failed to open temp dir: %v", err)
+	}
+	defer func() { _ = d.Close() }()
+	names, err := d.Readdirnames(-1)
+	if err != nil {
+		t.Fatalf("Failed to list temp dir: %v", err)
+	}
+	var order []string
+	for _, name := range names {
+		if filepath.Ext(name) == ".txt" {
+			order = append(order, name)
+		}
+	}
+	return order
+}
+
+func TestFileProcessor_PipelineCancelled(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_pipeline_cancel")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	if err := os.WriteFile(filepath.Join(tempDir, "report.pdf"), []byte("pdf"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	config := &model.Config{Silent: true}
+	stats := &model.Stats{StartTime: time.Now()}
+	processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := processor.ProcessDirectory(ctx, tempDir); err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if !helpers.FileExists(filepath.Join(tempDir, "report.pdf")) {
+		t.Errorf("Expected report.pdf to stay in place after cancellation")
+	}
+}
+
+// cancelLogger - cancels the run once the first file has been moved
+type cancelLogger struct {
+	once   sync.Once
+	cancel context.CancelFunc
+}
+
+func (l *cancelLogger) Log(_ model.Config, _ helpers.LogLevel, message string) {
+	if strings.HasPrefix(message, "Moved") {
+		l.once.Do(l.cancel)
+	}
+}
+
+func TestFileProcessor_InterruptedRunStats(t *testing.T) {
+	for _, duplicates := range []bool{false, true} {
+		t.Run(fmt.Sprintf("duplicates-%v", duplicates), func(t *testing.T) {
+			tempDir, err := os.MkdirTemp("", "gosorter_test_interrupted")
+			if err != nil {
+				t.Fatalf("Failed to create temp dir: %v", err)
+			}
+			defer func() {
+				if err := os.RemoveAll(tempDir); err != nil {
+					t.Fatalf("Failed to remove temp dir: %v", err)
+				}
+			}()
+
+			const count = 200
+			for i := range count {
+				name := fmt.Sprintf("report%03d.pdf", i)
+				// pairs of identical files for the duplicate pass
+				content := fmt.Sprintf("content %d", i/2)
+				if err := os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+					t.Fatalf("Failed to create test file: %v", err)
+				}
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			config := &model.Config{MoveDuplicates: duplicates}
+			stats := &model.Stats{StartTime: time.Now()}
+			processor := NewFileProcessor(config, stats, &cancelLogger{cancel: cancel})
+
+			if err := processor.ProcessDirectory(ctx, tempDir); err != context.Canceled {
+				t.Fatalf("Expected context.Canceled, got %v", err)
+			}
+
+			// every file is either still in place or fully moved, and the stats say which
+			var left, moved, dups int64
+			for i := range count {
+				name := fmt.Sprintf("report%03d.pdf", i)
+				if helpers.FileExists(filepath.Join(tempDir, name)) {
+					left++
+				}
+				if helpers.FileExists(filepath.Join(tempDir, "PDFs", name)) {
+					moved++
+				}
+			}
+			if entries, err := os.ReadDir(filepath.Join(tempDir, "Duplicates")); err == nil {
+				dups = int64(len(entries))
+			}
+			if left+moved+dups != count {
+				t.Errorf("Expected %d files accounted for, got %d in place, %d moved, %d duplicates", count, left, moved, dups)
+			}
+			if left == 0 {
+				t.Error("Expected the run to stop before all files were moved")
+			}
+			if got := stats.GetFilesMoved(); got != moved {
+				t.Errorf("Expected %d files moved in stats, got %d", moved, got)
+			}
+			if got := stats.GetDuplicatesMoved(); got != dups {
+				t.Errorf("Expected %d duplicates moved in stats, got %d", dups, got)
+			}
+			if got := stats.GetErrorsCount(); got != 0 {
+				t.Errorf("Expected no errors for an interrupted run, got %d", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+// Package service - pre-flight checks
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mohamedation/GoSorter/helpers"
+)
+
+// freeSpace - replaced in tests, a full drive cannot be made on demand
+var freeSpace = helpers.FreeSpace
+
+// preflight - finds what would make a run fail halfway, before anything is moved: directories
+// GoSorter cannot write to, folder names taken by files, and destination folders on another
+// drive (a symlink to a USB drive or a share) without room for the files copied there. all
+// problems are reported at once.
+// destinations are estimated from the extension mapping, layouts and templates only add
+// subfolders below it. files that stay in place (ignored, hidden, busy...) are not counted
+func (fp *FileProcessor) preflight(ctx context.Context, folderPath string, g *writeGuard) error {
+	var problems []string
+	if err := helpers.Writable(folderPath); err != nil {
+		problems = append(problems, fmt.Sprintf("cannot write to %s: %v", folderPath, err))
+	}
+	sourceFS, known := helpers.FilesystemID(folderPath)
+
+	// existing destination folders on other filesystems, by folder name
+	copied := make(map[string]*copyTarget)
+	for _, root := range fp.destinationRoots() {
+		path := helpers.TargetPath(folderPath, root)
+		info, err := os.Stat(path)
+		if err != nil {
+			continue // created in folderPath when needed
+		}
+		if !info.IsDir() {
+			problems = append(problems, fmt.Sprintf("%s is a file, not a folder%s", path, fp.folderKeys(root)))
+			continue
+		}
+		if err := helpers.Writable(path); err != nil {
+			problems = append(problems, fmt.Sprintf("cannot write to %s: %v", path, err))
+			continue
+		}
+		if fs, ok := helpers.FilesystemID(path); ok && known && fs != sourceFS {
+			copied[root] = &copyTarget{path: path, fs: fs}
+		}
+	}
+
+	if len(copied) > 0 && len(problems) == 0 {
+		err := scanDirectory(ctx, folderPath, func(entry os.DirEntry) bool {
+			if entry.IsDir() || fp.staysInPlace(g, entry) {
+				return true
+			}
+			ext := strings.ToLower(filepath.Ext(entry.Name()))
+			root := fp.destinationRoot(ext)
+			if ext == ".zip" && helpers.FolderExists(filepath.Join(folderPath, strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())))) {
+				root = firstSegment(fp.extConfig.ArchiveExtractedFolder)
+			}
+			target := copied[root]
+			if target == nil {
+				return true
+			}
+			if info, err := entryInfo(folderPath, entry); err == nil {
+				target.bytes += uint64(info.Size())
+			}
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		problems = append(problems, fp.checkFreeSpace(copied)...)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("pre-flight check failed, nothing was moved:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// copyTarget - destination folder on another filesystem and the bytes that will be copied there
+type copyTarget struct {
+	path  string
+	fs    string
+	bytes uint64
+}
+
+// checkFreeSpace - folders on the same drive share its free space
+func (fp *FileProcessor) checkFreeSpace(copied map[string]*copyTarget) []string {
+	needed := make(map[string]uint64)
+	folders := make(map[string][]string)
+	for _, target := range copied {
+		needed[target.fs] += target.bytes
+		folders[target.fs] = append(folders[target.fs], target.path)
+	}
+
+	var problems []string
+	for fs, bytes := range needed {
+		sort.Strings(folders[fs])
+		free, err := freeSpace(folders[fs][0])
+		if err != nil {
+			fp.Logger.Log(*fp.config, helpers.Debug, fmt.Sprintf("Cannot check free space of %s: %v\n", folders[fs][0], err))
+			continue
+		}
+		fp.Logger.Log(*fp.config, helpers.Debug, fmt.Sprintf("%s: %s to copy, %s free\n", strings.Join(folders[fs], ", "), formatBytes(bytes), formatBytes(free)))
+		if bytes > free {
+			problems = append(problems, fmt.Sprintf("not enough space for %s: %s to copy, %s free", strings.Join(folders[fs], ", "), formatBytes(bytes), formatBytes(free)))
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// destinationRoots - every top-level folder files can be moved to
+func (fp *FileProcessor) destinationRoots() []string {
+	ec := fp.extConfig
+	roots := make(map[string]bool)
+	for _, folder := range []string{ec.DuplicatesFolder, ec.ArchiveExtractedFolder, ec.TransparentPNGFolder, ec.VersionsFolder, ec.ScreenshotsFolder, ec.ScreenRecordingsFolder} {
+		roots[firstSegment(folder)] = true
+	}
+	for ext := range ec.ExtensionToFolder {
+		roots[fp.destinationRoot(ext)] = true
+	}
+	delete(roots, "")
+
+	names := make([]string, 0, len(roots))
+	for root := range roots {
+		names = append(names, root)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// folderKeys - configuration keys using a top-level folder, for messages
+func (fp *FileProcessor) folderKeys(root string) string {
+	var keys []string
+	for _, f := range fp.extConfig.Folders() {
+		if !f.Subpath && firstSegment(f.Folder) == root {
+			keys = append(keys, f.Key)
+		}
+	}
+	if len(keys) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(keys, ", ") + ")"
+}
+
+// destinationRoot - top-level folder of an extension, empty when it depends on the file
+func (fp *FileProcessor) destinationRoot(ext string) string {
+	if fp.config.DuplicatesOnly {
+		return ""
+	}
+	folder := fp.extConfig.ExtensionToFolder[ext]
+	if template := fp.extConfig.TemplateFor(ext); template.Folder != "" {
+		// {category} is the mapped folder
+		folder = strings.Replace(template.Folder, "{category}", folder, 1)
+	}
+	return firstSegment(folder)
+}
+
+// firstSegment - "PDFs/{yyyy}" -> "PDFs", empty for "{category}/...". folders outside the sorted
+// directory keep everything before the first token: "/mnt/nas/Photos/{yyyy}" -> "/mnt/nas/Photos"
+func firstSegment(folder string) string {
+	if filepath.IsAbs(folder) || strings.HasPrefix(filepath.ToSlash(folder), "../") {
+		static, _, templated := strings.Cut(folder, "{")
+		if templated {
+			static = static[:strings.LastIndexAny(static, `/\`)+1]
+		}
+		return filepath.Clean(static)
+	}
+	first, _, _ := strings.Cut(filepath.ToSlash(folder), "/")
+	if strings.Contains(first, "{") {
+		return ""
+	}
+	return first
+}
+
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,248 @@
+// Package service - pre-flight check tests
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mohamedation/GoSorter/helpers"
+	"github.com/mohamedation/GoSorter/model"
+)
+
+func TestFileProcessor_PreflightFreeSpace(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_preflight_space")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+	other := otherFilesystem(t, tempDir)
+
+	// PDFs is a symlink to another drive with 150 bytes left
+	if err := os.Symlink(other, filepath.Join(tempDir, "PDFs")); err != nil {
+		t.Skipf("Symlinks not available: %v", err)
+	}
+	originalFreeSpace := freeSpace
+	freeSpace = func(string) (uint64, error) { return 150, nil }
+	defer func() { freeSpace = originalFreeSpace }()
+
+	for _, name := range []string{"a.pdf", "b.pdf", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(strings.Repeat("x", 100)), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	config := &model.Config{Silent: true}
+	stats := &model.Stats{StartTime: time.Now()}
+	processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+	err = processor.ProcessDirectory(context.Background(), tempDir)
+	if err == nil || !strings.Contains(err.Error(), "not enough space") || !strings.Contains(err.Error(), "200 B to copy, 150 B free") {
+		t.Fatalf("Expected a free space error for 200 bytes, got %v", err)
+	}
+	for _, name := range []string{"a.pdf", "b.pdf", "notes.txt"} {
+		if !helpers.FileExists(filepath.Join(tempDir, name)) {
+			t.Errorf("Expected %s to stay in place", name)
+		}
+	}
+
+	// the .txt files stay on the same drive
+	freeSpace = func(string) (uint64, error) { return 200, nil }
+	if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+		t.Fatalf("Expected the run to fit, got %v", err)
+	}
+	if !helpers.FileExists(filepath.Join(other, "a.pdf")) || !helpers.FileExists(filepath.Join(other, "b.pdf")) {
+		t.Error("Expected the PDFs on the other drive")
+	}
+}
+
+func TestFileProcessor_PreflightSkipsIgnored(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_preflight_ignored")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+	other := otherFilesystem(t, tempDir)
+
+	if err := os.Symlink(other, filepath.Join(tempDir, "PDFs")); err != nil {
+		t.Skipf("Symlinks not available: %v", err)
+	}
+	originalFreeSpace := freeSpace
+	freeSpace = func(string) (uint64, error) { return 150, nil }
+	defer func() { freeSpace = originalFreeSpace }()
+
+	// only a.pdf is moved, the ignored and the partially downloaded file fit as well
+	for _, name := range []string{"a.pdf", "big.pdf", "movie.pdf.part"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(strings.Repeat("x", 100)), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, model.IgnoreFileName), []byte("big.pdf\n"), 0644); err != nil {
+		t.Fatalf("Failed to create ignore file: %v", err)
+	}
+
+	config := &model.Config{Silent: true}
+	stats := &model.Stats{StartTime: time.Now()}
+	processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+	if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+		t.Fatalf("Expected the run to fit, got %v", err)
+	}
+	if !helpers.FileExists(filepath.Join(other, "a.pdf")) || !helpers.FileExists(filepath.Join(tempDir, "big.pdf")) {
+		t.Error("Expected a.pdf on the other drive and big.pdf in place")
+	}
+}
+
+func TestFileProcessor_PreflightPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("permission bits do not restrict this user")
+	}
+	tempDir, err := os.MkdirTemp("", "gosorter_test_preflight_permissions")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	readOnly := filepath.Join(tempDir, "PDFs")
+	if err := os.Mkdir(readOnly, 0555); err != nil {
+		t.Fatalf("Failed to create read-only folder: %v", err)
+	}
+	defer func() { _ = os.Chmod(readOnly, 0750) }()
+	if err := os.WriteFile(filepath.Join(tempDir, "a.pdf"), []byte("a"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	config := &model.Config{Silent: true}
+	processor := NewFileProcessor(config, &model.Stats{StartTime: time.Now()}, &helpers.CLILogger{})
+	err = processor.ProcessDirectory(context.Background(), tempDir)
+	if err == nil || !strings.Contains(err.Error(), "cannot write to "+readOnly) {
+		t.Fatalf("Expected a permission error for %s, got %v", readOnly, err)
+	}
+	if !helpers.FileExists(filepath.Join(tempDir, "a.pdf")) {
+		t.Error("Expected a.pdf to stay in place")
+	}
+}
+
+func TestFileProcessor_PreflightFolderIsFile(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_preflight_file")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	// a file without extension named like the folder PDFs go to
+	for _, name := range []string{"PDFs", "a.pdf"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	config := &model.Config{Silent: true}
+	stats := &model.Stats{StartTime: time.Now()}
+	processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+	err = processor.ProcessDirectory(context.Background(), tempDir)
+	if err == nil || !strings.Contains(err.Error(), "is a file, not a folder (extension_to_folder[.pdf])") {
+		t.Fatalf("Expected an error naming extension_to_folder[.pdf], got %v", err)
+	}
+	if !helpers.FileExists(filepath.Join(tempDir, "a.pdf")) {
+		t.Error("Expected a.pdf to stay in place")
+	}
+}
+
+func TestFileProcessor_PreflightRenamedArchives(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_preflight_archives")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	// archives go to Compressed, a file named Archives is in nobody's way
+	for _, name := range []string{"Archives", "a.zip"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	config := &model.Config{Silent: true}
+	stats := &model.Stats{StartTime: time.Now()}
+	processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+	for ext, folder := range processor.extConfig.ExtensionToFolder {
+		if folder == "Archives" {
+			processor.extConfig.ExtensionToFolder[ext] = "Compressed"
+		}
+	}
+	if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+		t.Fatalf("ProcessDirectory failed: %v", err)
+	}
+	if !helpers.FileExists(filepath.Join(tempDir, "Compressed", "a.zip")) {
+		t.Error("Expected Compressed/a.zip")
+	}
+}
+
+func TestFileProcessor_OutsideFolders(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_outside")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+	sortDir := filepath.Join(tempDir, "sort")
+	archive := filepath.Join(tempDir, "archive", "PDFs")
+	if err := os.MkdirAll(sortDir, 0750); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	for _, name := range []string{"a.pdf", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(sortDir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	config := &model.Config{Silent: true}
+	stats := &model.Stats{StartTime: time.Now()}
+	processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+	processor.extConfig.ExtensionToFolder[".pdf"] = archive
+	processor.extConfig.ExtensionToFolder[".txt"] = filepath.Join("..", "archive", "Text")
+	err = processor.ProcessDirectory(context.Background(), sortDir)
+	if err == nil || !strings.Contains(err.Error(), "extension_to_folder[.pdf]") || !strings.Contains(err.Error(), "extension_to_folder[.txt]") {
+		t.Fatalf("Expected both folders to be rejected, got %v", err)
+	}
+	if helpers.FolderExists(filepath.Join(tempDir, "archive")) {
+		t.Fatal("Expected nothing to be moved")
+	}
+
+	processor.extConfig.AllowOutsideFolders = true
+	if err := processor.ProcessDirectory(context.Background(), sortDir); err != nil {
+		t.Fatalf("ProcessDirectory failed: %v", err)
+	}
+	if !helpers.FileExists(filepath.Join(archive, "a.pdf")) {
+		t.Error("Expected a.pdf in the absolute folder")
+	}
+	if !helpers.FileExists(filepath.Join(tempDir, "archive", "Text", "notes.txt")) {
+		t.Error("Expected notes.txt in the folder next to the sorted directory")
+	}
+}
@@ -0,0 +1,61 @@
+// Package service - conflict prompt
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/mohamedation/GoSorter/model"
+)
+
+// conflictPrompter - asks what to do with a conflicting file, one question at a time
+// even with several move workers. a capital answer applies to every remaining conflict
+type conflictPrompter struct {
+	mu     sync.Mutex
+	in     *bufio.Reader
+	out    io.Writer
+	always string
+}
+
+func newConflictPrompter(in io.Reader, out io.Writer) *conflictPrompter {
+	return &conflictPrompter{in: bufio.NewReader(in), out: out}
+}
+
+// ask - skip, rename or overwrite. without input (EOF, closed stdin) files are skipped
+func (p *conflictPrompter) ask(src, dst string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.always != "" {
+		return p.always
+	}
+
+	for {
+		fmt.Fprintf(p.out, "%s already exists (incoming %s)\n[s]kip, [r]ename, [o]verwrite, capital letter for all: ", dst, src)
+		line, err := p.in.ReadString('\n')
+		answer := strings.TrimSpace(line)
+		if answer == "" && err != nil {
+			fmt.Fprintln(p.out)
+			p.always = model.ConflictSkip
+			return p.always
+		}
+
+		var policy string
+		switch strings.ToLower(answer) {
+		case "s", "skip":
+			policy = model.ConflictSkip
+		case "r", "rename":
+			policy = model.ConflictRename
+		case "o", "overwrite":
+			policy = model.ConflictOverwrite
+		default:
+			continue
+		}
+		if answer != strings.ToLower(answer) {
+			p.always = policy
+		}
+		return policy
+	}
+}
@@ -0,0 +1,114 @@
+// Package service - dangerous root tests
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mohamedation/GoSorter/helpers"
+	"github.com/mohamedation/GoSorter/model"
+)
+
+func TestFileProcessor_RefusesDangerousRoots(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, dir string)
+	}{
+		{
+			name: "go module",
+			setup: func(t *testing.T, dir string) {
+				if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/x\n"), 0644); err != nil {
+					t.Fatalf("Failed to create go.mod: %v", err)
+				}
+			},
+		},
+		{
+			name: "git repository",
+			setup: func(t *testing.T, dir string) {
+				if err := os.Mkdir(filepath.Join(dir, ".git"), 0750); err != nil {
+					t.Fatalf("Failed to create .git: %v", err)
+				}
+			},
+		},
+		{
+			name: "home directory",
+			setup: func(t *testing.T, dir string) {
+				for _, env := range []string{"HOME", "USERPROFILE"} {
+					original, had := os.LookupEnv(env)
+					if err := os.Setenv(env, dir); err != nil {
+						t.Fatalf("Failed to set %s: %v", env, err)
+					}
+					t.Cleanup(func() {
+						if had {
+							_ = os.Setenv(env, original)
+						} else {
+							_ = os.Unsetenv(env)
+						}
+					})
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir, err := os.MkdirTemp("", "gosorter_test_roots")
+			if err != nil {
+				t.Fatalf("Failed to create temp dir: %v", err)
+			}
+			defer func() {
+				if err := os.RemoveAll(tempDir); err != nil {
+					t.Fatalf("Failed to remove temp dir: %v", err)
+				}
+			}()
+			tt.setup(t, tempDir)
+			for _, name := range []string{"main.json", "notes.txt"} {
+				if err := os.WriteFile(filepath.Join(tempDir, name), []byte(name), 0644); err != nil {
+					t.Fatalf("Failed to create test file: %v", err)
+				}
+			}
+
+			config := &model.Config{Silent: true}
+			stats := &model.Stats{StartTime: time.Now()}
+			processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+			err = processor.ProcessDirectory(context.Background(), tempDir)
+			var rootErr *helpers.DangerousRootError
+			if !errors.As(err, &rootErr) {
+				t.Fatalf("Expected a DangerousRootError, got %v", err)
+			}
+			for _, name := range []string{"main.json", "notes.txt"} {
+				if !helpers.FileExists(filepath.Join(tempDir, name)) {
+					t.Errorf("Expected %s to stay in place", name)
+				}
+			}
+
+			// -f
+			config.Force = true
+			if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+				t.Fatalf("ProcessDirectory with Force failed: %v", err)
+			}
+			if !helpers.FileExists(filepath.Join(tempDir, "JSONs", "main.json")) {
+				t.Error("Expected main.json to be sorted with Force")
+			}
+		})
+	}
+}
+
+func TestFileProcessor_RefusesFilesystemRoot(t *testing.T) {
+	root := string(filepath.Separator)
+	if vol := filepath.VolumeName(os.TempDir()); vol != "" {
+		root = vol + root
+	}
+	config := &model.Config{Silent: true}
+	stats := &model.Stats{StartTime: time.Now()}
+	processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+	err := processor.ProcessDirectory(context.Background(), root)
+	var rootErr *helpers.DangerousRootError
+	if !errors.As(err, &rootErr) {
+		t.Fatalf("Expected a DangerousRootError for %s, got %v", root, err)
+	}
+}
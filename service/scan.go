@@ -0,0 +1,172 @@
+// Package service - directory scanning
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mohamedation/GoSorter/helpers"
+	"github.com/mohamedation/GoSorter/model"
+)
+
+// scanBatchSize - directory entries read per ReadDir call
+const scanBatchSize = 1024
+
+// scanDirectory - streams the entries of folderPath in directory order, scanBatchSize at a time,
+// so huge directories are neither held in memory nor sorted before work can start.
+// returning false from fn stops the scan
+func scanDirectory(ctx context.Context, folderPath string, fn func(os.DirEntry) bool) error {
+	dir, err := os.Open(filepath.Clean(folderPath))
+	if err != nil {
+		return fmt.Errorf("error reading directory: %w", err)
+	}
+	defer func() { _ = dir.Close() }()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		batch, err := dir.ReadDir(scanBatchSize)
+		for _, entry := range batch {
+			if entry.Name() == helpers.LockFileName || entry.Name() == model.IgnoreFileName {
+				continue
+			}
+			if !fn(entry) {
+				return nil
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading directory: %w", err)
+		}
+	}
+}
+
+// readDirectory - the whole listing sorted by name, for the stages that compare files
+// with each other (duplicates, copy families)
+func readDirectory(ctx context.Context, folderPath string) ([]os.DirEntry, error) {
+	var entries []os.DirEntry
+	err := scanDirectory(ctx, folderPath, func(entry os.DirEntry) bool {
+		entries = append(entries, entry)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// entryInfo - FileInfo from the directory listing, symlinks are followed like os.Stat did
+func entryInfo(folderPath string, entry os.DirEntry) (os.FileInfo, error) {
+	if entry.Type()&os.ModeSymlink != 0 {
+		return os.Stat(filepath.Join(folderPath, entry.Name()))
+	}
+	return entry.Info()
+}
+
+// listEntries - scan function over an already read listing
+func listEntries(entries []os.DirEntry) func(func(os.DirEntry) bool) error {
+	return func(yield func(os.DirEntry) bool) error {
+		for _, entry := range entries {
+			if !yield(entry) {
+				return nil
+			}
+		}
+		return nil
+	}
+}
+
+// skipEntry - logs and counts entries that stay in place: special files, ignored files, hidden
+// files, junk files and symlinks by their policies, and files still being written. junk files
+// are deleted here when junk_files is delete
+func (fp *FileProcessor) skipEntry(g *writeGuard, entry os.DirEntry) bool {
+	if entry.IsDir() {
+		return false
+	}
+	name := entry.Name()
+	if reason := fp.entryPolicy(g.folder, entry); reason != "" {
+		fp.stats.IncrementSkipped()
+		fp.Logger.Log(*fp.config, helpers.Debug, fmt.Sprintf("Skipping %s: %s\n", name, reason))
+		return true
+	}
+	if fp.ignore.Ignored(name, false) {
+		fp.stats.IncrementIgnored()
+		fp.Logger.Log(*fp.config, helpers.Debug, fmt.Sprintf("Ignoring %s\n", name))
+		return true
+	}
+	if model.IsJunkFile(name) && fp.extConfig.JunkFiles == model.EntryDelete {
+		if err := helpers.Discard(filepath.Join(g.folder, name), *fp.config, fp.Logger); err != nil {
+			fp.stats.IncrementErrors()
+			fp.Logger.Log(*fp.config, helpers.Error, fmt.Sprintf("Failed to delete junk file %s: %v\n", name, err))
+			return true
+		}
+		fp.stats.IncrementJunkDeleted()
+		fp.Logger.Log(*fp.config, helpers.Info, fmt.Sprintf("Deleted junk file: %s\n", name))
+		return true
+	}
+	if reason := g.busy(entry); reason != "" {
+		fp.stats.IncrementInProgressSkipped()
+		fp.Logger.Log(*fp.config, helpers.Info, fmt.Sprintf("Skipped: %s %s\n", name, reason))
+		return true
+	}
+	return false
+}
+
+// staysInPlace - skipEntry without logging, counting or deleting junk, for estimates
+func (fp *FileProcessor) staysInPlace(g *writeGuard, entry os.DirEntry) bool {
+	name := entry.Name()
+	return fp.entryPolicy(g.folder, entry) != "" ||
+		fp.ignore.Ignored(name, false) ||
+		(model.IsJunkFile(name) && fp.extConfig.JunkFiles == model.EntryDelete) ||
+		g.busy(entry) != ""
+}
+
+// specialModes - devices, pipes and sockets. opening a FIFO for hashing blocks until a writer shows up
+const specialModes = os.ModeDevice | os.ModeCharDevice | os.ModeNamedPipe | os.ModeSocket | os.ModeIrregular
+
+// entryPolicy - why the entry is skipped by type, empty when it is processed
+func (fp *FileProcessor) entryPolicy(folderPath string, entry os.DirEntry) string {
+	name := entry.Name()
+	if entry.Type()&specialModes != 0 {
+		return "not a regular file"
+	}
+	if entry.Type()&os.ModeSymlink != 0 {
+		if fp.extConfig.Symlinks == model.EntrySkip {
+			return "symlink"
+		}
+		// the link is moved, not its target, but directories and special files stay
+		if info, err := os.Stat(filepath.Join(folderPath, name)); err == nil && (info.IsDir() || info.Mode()&specialModes != 0) {
+			return "symlink to a directory or special file"
+		}
+	}
+	if model.IsJunkFile(name) {
+		if fp.extConfig.JunkFiles == model.EntrySkip {
+			return "junk file"
+		}
+		return ""
+	}
+	if strings.HasPrefix(name, ".") && fp.extConfig.HiddenFiles == model.EntrySkip {
+		return "hidden file"
+	}
+	return ""
+}
+
+// withoutSkipped - the listing without the entries skipEntry leaves in place
+func (fp *FileProcessor) withoutSkipped(g *writeGuard, entries []os.DirEntry) []os.DirEntry {
+	kept := entries[:0]
+	for _, entry := range entries {
+		if !fp.skipEntry(g, entry) {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
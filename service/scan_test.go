@@ -0,0 +1,119 @@
+// Package service - directory scanning tests
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mohamedation/GoSorter/helpers"
+	"github.com/mohamedation/GoSorter/model"
+)
+
+func TestFileProcessor_StreamingScan(t *testing.T) {
+	modes := []struct {
+		name   string
+		config model.Config
+	}{
+		{"streamed", model.Config{Silent: true}},
+		{"duplicates", model.Config{Silent: true, MoveDuplicates: true}},
+	}
+	for _, mode := range modes {
+		t.Run(mode.name, func(t *testing.T) {
+			tempDir, err := os.MkdirTemp("", "gosorter_test_scan")
+			if err != nil {
+				t.Fatalf("Failed to create temp dir: %v", err)
+			}
+			defer func() {
+				if err := os.RemoveAll(tempDir); err != nil {
+					t.Fatalf("Failed to remove temp dir: %v", err)
+				}
+			}()
+
+			// spans several ReadDir batches, sidecars and their photos end up in different ones
+			count := scanBatchSize + scanBatchSize/2
+			for i := range count {
+				name := fmt.Sprintf("IMG_%05d.JPG", i)
+				if err := os.WriteFile(filepath.Join(tempDir, name), []byte(name), 0644); err != nil {
+					t.Fatalf("Failed to create test file: %v", err)
+				}
+			}
+			for _, i := range []int{0, scanBatchSize, count - 1} {
+				name := fmt.Sprintf("IMG_%05d.xmp", i)
+				if err := os.WriteFile(filepath.Join(tempDir, name), []byte(name), 0644); err != nil {
+					t.Fatalf("Failed to create test file: %v", err)
+				}
+			}
+			if err := os.WriteFile(filepath.Join(tempDir, "orphan.xmp"), []byte("no photo"), 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+
+			config := mode.config
+			stats := &model.Stats{StartTime: time.Now()}
+			processor := NewFileProcessor(&config, stats, &helpers.CLILogger{})
+
+			if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			for _, i := range []int{0, scanBatchSize, count - 1} {
+				for _, ext := range []string{".JPG", ".xmp"} {
+					path := filepath.Join(tempDir, "Pictures", fmt.Sprintf("IMG_%05d%s", i, ext))
+					if !helpers.FileExists(path) {
+						t.Errorf("Expected file at %s", path)
+					}
+				}
+			}
+			if !helpers.FileExists(filepath.Join(tempDir, "orphan.xmp")) {
+				t.Error("Expected orphan.xmp to stay in place")
+			}
+			if got, want := stats.GetFilesMoved(), int64(count+3); got != want {
+				t.Errorf("Expected %d files moved, got %d", want, got)
+			}
+			if got, want := stats.GetTotalFiles(), int64(count+4); got != want {
+				t.Errorf("Expected %d files in total, got %d", want, got)
+			}
+		})
+	}
+}
+
+func TestReadDirectory_Sorted(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_readdir")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	count := scanBatchSize + 10
+	for i := count - 1; i >= 0; i-- {
+		if err := os.WriteFile(filepath.Join(tempDir, fmt.Sprintf("f%05d", i)), nil, 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	entries, err := readDirectory(context.Background(), tempDir)
+	if err != nil {
+		t.Fatalf("readDirectory failed: %v", err)
+	}
+	if len(entries) != count {
+		t.Fatalf("Expected %d entries, got %d", count, len(entries))
+	}
+	for i, entry := range entries {
+		if want := fmt.Sprintf("f%05d", i); entry.Name() != want {
+			t.Fatalf("Entry %d is %s, want %s", i, entry.Name(), want)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := readDirectory(ctx, tempDir); err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+// Package service - screenshot detection
+package service
+
+import (
+	"fmt"
+
+	"github.com/mohamedation/GoSorter/helpers"
+	"github.com/mohamedation/GoSorter/model"
+)
+
+// formats screenshot tools save in
+var screenshotExtensions = map[string]bool{
+	".png":  true,
+	".jpg":  true,
+	".jpeg": true,
+}
+
+// screenCaptureFolder - screenshots or screen recordings folder, empty for anything else
+func (fp *FileProcessor) screenCaptureFolder(file model.FileDetail) string {
+	if videoExtensions[file.Ext] && helpers.IsScreenRecordingName(file.Name) {
+		return fp.extConfig.ScreenRecordingsFolder
+	}
+	if !screenshotExtensions[file.Ext] {
+		return ""
+	}
+	if helpers.IsScreenshotName(file.Name) || fp.looksLikeScreenshot(file) {
+		return fp.extConfig.ScreenshotsFolder
+	}
+	return ""
+}
+
+// looksLikeScreenshot - screen sized and not taken by a camera
+func (fp *FileProcessor) looksLikeScreenshot(file model.FileDetail) bool {
+	width, height, err := helpers.ImageSize(file.Path)
+	if err != nil {
+		fp.Logger.Log(*fp.config, helpers.Debug, fmt.Sprintf("Could not read image size of %s: %v\n", file.Name, err))
+		return false
+	}
+	if !helpers.IsDisplayResolution(width, height) {
+		return false
+	}
+	if exif, err := helpers.ReadExif(file.Path); err == nil && (exif.Make != "" || exif.Model != "") {
+		return false
+	}
+	return true
+}
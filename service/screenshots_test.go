@@ -0,0 +1,115 @@
+// Package service - screenshot detection tests
+package service
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mohamedation/GoSorter/helpers"
+	"github.com/mohamedation/GoSorter/model"
+)
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// encodeJPEG - real JPEG, with an APP1 Exif segment after SOI when tiff is set
+func encodeJPEG(t *testing.T, width, height int, tiff []byte) []byte {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height)), nil); err != nil {
+		t.Fatalf("Failed to encode JPEG: %v", err)
+	}
+	data := buf.Bytes()
+	if tiff == nil {
+		return data
+	}
+	app1 := buildExifJPEG(tiff)
+	app1 = app1[2 : len(app1)-2] // strip SOI/EOI
+	return append(append([]byte{0xFF, 0xD8}, app1...), data[2:]...)
+}
+
+func TestIsScreenshotName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"Screenshot from 2024-03-01 10-15-00.png", true},
+		{"Screenshot_20240301_101500.png", true},
+		{"Screenshot_20240301-101500_Chrome.jpg", true},
+		{"Screenshot 2024-03-01 at 10.15.00.png", true},
+		{"Screen Shot 2019-03-01 at 10.15.00 AM.png", true},
+		{"Screenshot (12).png", true},
+		{"screenshots-guide.png", false},
+		{"IMG_20240301_101500.jpg", false},
+	}
+	for _, tt := range tests {
+		if got := helpers.IsScreenshotName(tt.name); got != tt.want {
+			t.Errorf("IsScreenshotName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFileProcessor_Screenshots(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_screenshots")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	camera := buildExifTIFF("Canon", "Canon EOS R6", "2024:07:15 10:30:00")
+	testFiles := map[string][]byte{
+		"Screenshot from 2024-03-01 10-15-00.png": encodePNG(t, 64, 64),
+		"image.png":                       encodePNG(t, 1920, 1080),
+		"phone.jpg":                       encodeJPEG(t, 1080, 2400, nil),
+		"camera.jpg":                      encodeJPEG(t, 1920, 1080, camera),
+		"icon.png":                        encodePNG(t, 64, 64),
+		"Screen Recording 2024-03-01.mov": []byte("not a video"),
+		"holiday.mov":                     []byte("not a video"),
+	}
+	for file, data := range testFiles {
+		if err := os.WriteFile(filepath.Join(tempDir, file), data, 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", file, err)
+		}
+	}
+
+	config := &model.Config{Silent: true, DetectScreenshots: true}
+	stats := &model.Stats{StartTime: time.Now()}
+	processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+
+	if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+		t.Fatalf("ProcessDirectory with screenshot detection failed: %v", err)
+	}
+
+	expected := []string{
+		filepath.Join(tempDir, "Screenshots", "Screenshot from 2024-03-01 10-15-00.png"),
+		filepath.Join(tempDir, "Screenshots", "image.png"),
+		filepath.Join(tempDir, "Screenshots", "phone.jpg"),
+		filepath.Join(tempDir, "Pictures", "camera.jpg"),
+		filepath.Join(tempDir, "Pictures", "icon.png"),
+		filepath.Join(tempDir, "Screen Recordings", "Screen Recording 2024-03-01.mov"),
+		filepath.Join(tempDir, "Videos", "holiday.mov"),
+	}
+	for _, path := range expected {
+		if !helpers.FileExists(path) {
+			t.Errorf("Expected file at %s", path)
+		}
+	}
+	if got := stats.GetScreenshotsMoved(); got != 4 {
+		t.Errorf("Expected 4 screen captures moved, got %d", got)
+	}
+}
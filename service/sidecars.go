@@ -0,0 +1,231 @@
+// Package service - sidecar grouping
+package service
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mohamedation/GoSorter/helpers"
+	"github.com/mohamedation/GoSorter/model"
+)
+
+type sidecarFile struct {
+	name   string // current file name
+	suffix string // what follows the primary's stem (".en.srt"), or its full name (".sig")
+	full   bool   // named after the primary's full name: "file.tar.gz.sig", "IMG_0001.CR2.xmp"
+}
+
+// groupSidecars - takes companion files out of entries and remembers them under the file
+// they belong to, so they end up next to it whatever folder and name it gets
+func (fp *FileProcessor) groupSidecars(entries []os.DirEntry) []os.DirEntry {
+	fp.sidecars = make(map[string][]sidecarFile)
+	if len(fp.extConfig.Sidecars) == 0 {
+		return entries
+	}
+
+	names := make(map[string]string) // lowercase name -> name
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names[strings.ToLower(entry.Name())] = entry.Name()
+		}
+	}
+	lookup := func(name string) (string, bool) {
+		parent, ok := names[strings.ToLower(name)]
+		return parent, ok
+	}
+
+	parents := make(map[string]string)
+	found := make(map[string]sidecarFile)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if parent, sidecar, ok := fp.sidecarParent(entry.Name(), lookup); ok {
+			parents[entry.Name()] = parent
+			found[entry.Name()] = sidecar
+		}
+	}
+
+	grouped := fp.attachSidecars(parents, found)
+	kept := make([]os.DirEntry, 0, len(entries))
+	for _, entry := range entries {
+		if !grouped[entry.Name()] {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
+
+// findSidecars - groupSidecars for a streamed directory. candidates are checked against the
+// files next to them with Lstat, so memory only holds the pairs actually found and not the
+// whole listing. sidecars and primaries that stay in place (skipEntry) are not paired, as in
+// groupSidecars. returns the names of the grouped sidecars, which the scan has to skip
+func (fp *FileProcessor) findSidecars(ctx context.Context, folderPath string, g *writeGuard) (map[string]bool, error) {
+	fp.sidecars = make(map[string][]sidecarFile)
+	if len(fp.extConfig.Sidecars) == 0 {
+		return nil, nil
+	}
+
+	// the listing is not at hand for case-insensitive matching, "IMG_0001.xmp" still
+	// finds "IMG_0001.CR2" by trying the extension in both cases
+	lookup := func(name string) (string, bool) {
+		ext := filepath.Ext(name)
+		stem := strings.TrimSuffix(name, ext)
+		for _, candidate := range []string{name, stem + strings.ToLower(ext), stem + strings.ToUpper(ext)} {
+			info, err := os.Lstat(filepath.Join(folderPath, candidate))
+			if err == nil && !info.IsDir() && !fp.staysInPlace(g, fs.FileInfoToDirEntry(info)) {
+				return candidate, true
+			}
+		}
+		return "", false
+	}
+
+	parents := make(map[string]string)
+	found := make(map[string]sidecarFile)
+	err := scanDirectory(ctx, folderPath, func(entry os.DirEntry) bool {
+		if entry.IsDir() || fp.staysInPlace(g, entry) {
+			return true
+		}
+		if parent, sidecar, ok := fp.sidecarParent(entry.Name(), lookup); ok {
+			parents[entry.Name()] = parent
+			found[entry.Name()] = sidecar
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fp.attachSidecars(parents, found), nil
+}
+
+// attachSidecars - records the matched sidecars under their primary file, returns the ones grouped
+func (fp *FileProcessor) attachSidecars(parents map[string]string, found map[string]sidecarFile) map[string]bool {
+	// a sidecar of a sidecar (.xmp next to a .dng next to a .cr2) follows the chain,
+	// chains that never reach a regular file (cyclic user rules) are sorted on their own
+	rooted := func(name string) bool {
+		seen := map[string]bool{strings.ToLower(name): true}
+		for {
+			parent, ok := parents[name]
+			if !ok {
+				return true
+			}
+			if seen[strings.ToLower(parent)] {
+				return false
+			}
+			seen[strings.ToLower(parent)] = true
+			name = parent
+		}
+	}
+
+	names := make([]string, 0, len(parents))
+	for name := range parents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	grouped := make(map[string]bool, len(names))
+	for _, name := range names {
+		if !rooted(name) {
+			continue
+		}
+		parent := parents[name]
+		fp.Logger.Log(*fp.config, helpers.Debug, fmt.Sprintf("[DEBUG] %s goes with %s\n", name, parent))
+		key := strings.ToLower(parent)
+		fp.sidecars[key] = append(fp.sidecars[key], found[name])
+		fp.stats.IncrementTotalFiles()
+		grouped[name] = true
+	}
+	return grouped
+}
+
+// sidecarParent - the file a sidecar belongs to, lookup resolves a candidate name to an existing file
+func (fp *FileProcessor) sidecarParent(name string, lookup func(string) (string, bool)) (string, sidecarFile, bool) {
+	ext := strings.ToLower(filepath.Ext(name))
+	primaries := fp.extConfig.Sidecars[ext]
+	if len(primaries) == 0 {
+		return "", sidecarFile{}, false
+	}
+	rest := strings.TrimSuffix(name, filepath.Ext(name))
+
+	if parent, ok := lookup(rest); ok && isSidecarPrimary(parent, primaries) {
+		return parent, sidecarFile{name: name, suffix: name[len(rest):], full: true}, true
+	}
+
+	// "movie.en.srt", "movie.en.forced.srt" keep their language tags
+	stem := rest
+	for range 3 {
+		for _, primary := range primaries {
+			if primary == model.AnyExtension {
+				continue
+			}
+			if parent, ok := lookup(stem + primary); ok {
+				return parent, sidecarFile{name: name, suffix: name[len(stem):]}, true
+			}
+		}
+		tag := filepath.Ext(stem)
+		if tag == "" || tag == stem {
+			break
+		}
+		stem = strings.TrimSuffix(stem, tag)
+	}
+	return "", sidecarFile{}, false
+}
+
+func isSidecarPrimary(name string, primaries []string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, primary := range primaries {
+		if primary == model.AnyExtension || primary == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// moveSidecars - moves the sidecars of primaryName next to dstPath, renamed along with it
+func (fp *FileProcessor) moveSidecars(ctx context.Context, folderPath, primaryName, dstPath string) {
+	sidecars := fp.sidecars[strings.ToLower(primaryName)]
+	if len(sidecars) == 0 || dstPath == "" {
+		return
+	}
+	targetFolder, err := filepath.Rel(folderPath, filepath.Dir(dstPath))
+	if err != nil {
+		fp.stats.IncrementErrors()
+		fp.Logger.Log(*fp.config, helpers.Error, fmt.Sprintf("Failed to place sidecars of %s: %v\n", primaryName, err))
+		return
+	}
+
+	// sidecars follow the policy of their primary file, without asking again
+	conflict := fp.conflictFor(strings.ToLower(filepath.Ext(primaryName)))
+	conflict.Ask = nil
+
+	primary := filepath.Base(dstPath)
+	for _, sidecar := range sidecars {
+		prefix := primary
+		if !sidecar.full {
+			prefix = strings.TrimSuffix(primary, filepath.Ext(primary))
+		}
+		moved, outcome := helpers.PlaceFile(ctx, folderPath, sidecar.name, targetFolder, prefix+sidecar.suffix, conflict, *fp.config, fp.Logger)
+		switch outcome {
+		case helpers.Skipped:
+			fp.stats.IncrementConflictsSkipped()
+			continue
+		case helpers.Unchanged:
+			continue
+		case helpers.Duplicated:
+			fp.stats.IncrementDuplicatesMoved()
+			continue
+		case helpers.Failed:
+			if ctx.Err() == nil {
+				fp.stats.IncrementErrors()
+			}
+			continue
+		}
+		fp.stats.IncrementFilesMoved()
+		fp.moveSidecars(ctx, folderPath, sidecar.name, moved)
+	}
+}
@@ -0,0 +1,183 @@
+// Package service - sidecar grouping tests
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mohamedation/GoSorter/helpers"
+	"github.com/mohamedation/GoSorter/model"
+)
+
+func TestFileProcessor_Sidecars(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_sidecars")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	testFiles := map[string]string{
+		"movie.mkv":          "new movie",
+		"movie.en.srt":       "subtitles",
+		"movie.nfo":          "info",
+		"IMG_0001.CR2":       "raw",
+		"IMG_0001.dng":       "converted raw",
+		"IMG_0001.xmp":       "edits",
+		"album.flac":         "audio",
+		"album.cue":          "cue sheet",
+		"tool.tar.gz":        "archive",
+		"tool.tar.gz.sha256": "checksum",
+		"orphan.srt":         "no video",
+		"install.log":        "no flac",
+	}
+	for file, content := range testFiles {
+		if err := os.WriteFile(filepath.Join(tempDir, file), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", file, err)
+		}
+	}
+	// forces movie.mkv to be renamed to movie(1).mkv
+	if err := os.MkdirAll(filepath.Join(tempDir, "Videos"), 0750); err != nil {
+		t.Fatalf("Failed to create Videos folder: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "Videos", "movie.mkv"), []byte("old movie"), 0644); err != nil {
+		t.Fatalf("Failed to create existing video: %v", err)
+	}
+
+	config := &model.Config{Silent: true}
+	stats := &model.Stats{StartTime: time.Now()}
+	processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+
+	if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+		t.Fatalf("ProcessDirectory with sidecars failed: %v", err)
+	}
+
+	expected := []string{
+		filepath.Join(tempDir, "Videos", "movie(1).mkv"),
+		filepath.Join(tempDir, "Videos", "movie(1).en.srt"),
+		filepath.Join(tempDir, "Videos", "movie(1).nfo"),
+		filepath.Join(tempDir, "RawImages", "IMG_0001.CR2"),
+		filepath.Join(tempDir, "RawImages", "IMG_0001.dng"),
+		filepath.Join(tempDir, "RawImages", "IMG_0001.xmp"),
+		filepath.Join(tempDir, "Music", "album.flac"),
+		filepath.Join(tempDir, "Music", "album.cue"),
+		filepath.Join(tempDir, "Archives", "tool.tar.gz"),
+		filepath.Join(tempDir, "Archives", "tool.tar.gz.sha256"),
+		filepath.Join(tempDir, "orphan.srt"),
+		filepath.Join(tempDir, "install.log"),
+	}
+	for _, path := range expected {
+		if !helpers.FileExists(path) {
+			t.Errorf("Expected file at %s", path)
+		}
+	}
+}
+
+func TestFileProcessor_SidecarsOfSkippedFiles(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		config model.Config
+	}{
+		{"streamed", model.Config{Silent: true, QuietPeriod: time.Hour}},
+		{"duplicates", model.Config{Silent: true, QuietPeriod: time.Hour, MoveDuplicates: true}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tempDir, err := os.MkdirTemp("", "gosorter_test_sidecars_skipped")
+			if err != nil {
+				t.Fatalf("Failed to create temp dir: %v", err)
+			}
+			defer func() {
+				if err := os.RemoveAll(tempDir); err != nil {
+					t.Fatalf("Failed to remove temp dir: %v", err)
+				}
+			}()
+
+			old := time.Now().Add(-2 * time.Hour)
+			testFiles := map[string]time.Time{
+				"movie.mkv":    old,
+				"movie.nfo":    time.Now(), // still being written, stays for the next run
+				"movie.en.srt": old,        // ignored
+				"clip.mp4":     old,        // ignored
+				"clip.nfo":     old,        // sorted on its own
+			}
+			for file, mtime := range testFiles {
+				path := filepath.Join(tempDir, file)
+				if err := os.WriteFile(path, []byte(file), 0644); err != nil {
+					t.Fatalf("Failed to create test file %s: %v", file, err)
+				}
+				if err := os.Chtimes(path, mtime, mtime); err != nil {
+					t.Fatalf("Failed to set mtime: %v", err)
+				}
+			}
+			if err := os.WriteFile(filepath.Join(tempDir, model.IgnoreFileName), []byte("movie.en.srt\nclip.mp4\n"), 0644); err != nil {
+				t.Fatalf("Failed to create ignore file: %v", err)
+			}
+
+			config := tc.config
+			stats := &model.Stats{StartTime: time.Now()}
+			processor := NewFileProcessor(&config, stats, &helpers.CLILogger{})
+			processor.extConfig.ExtensionToFolder[".nfo"] = "Info"
+			if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+				t.Fatalf("ProcessDirectory failed: %v", err)
+			}
+
+			for _, path := range []string{"Videos/movie.mkv", "movie.nfo", "movie.en.srt", "clip.mp4", "Info/clip.nfo"} {
+				if !helpers.FileExists(filepath.Join(tempDir, filepath.FromSlash(path))) {
+					t.Errorf("Expected %s", path)
+				}
+			}
+		})
+	}
+}
+
+func TestFileProcessor_SidecarsOfDuplicates(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_sidecars_duplicates")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	testFiles := map[string]string{
+		"movie.mkv":     "same movie",
+		"movie.srt":     "subtitles",
+		"movie2.mkv":    "same movie",
+		"movie2.en.srt": "other subtitles",
+	}
+	for file, content := range testFiles {
+		if err := os.WriteFile(filepath.Join(tempDir, file), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", file, err)
+		}
+	}
+
+	config := &model.Config{Silent: true, MoveDuplicates: true}
+	stats := &model.Stats{StartTime: time.Now()}
+	processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+	if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+		t.Fatalf("ProcessDirectory failed: %v", err)
+	}
+
+	expected := []string{
+		filepath.Join(tempDir, "Videos", "movie.mkv"),
+		filepath.Join(tempDir, "Videos", "movie.srt"),
+		filepath.Join(tempDir, "Duplicates", "movie2_duplicate_of_movie.mkv"),
+		filepath.Join(tempDir, "Duplicates", "movie2_duplicate_of_movie.en.srt"),
+	}
+	for _, path := range expected {
+		if !helpers.FileExists(path) {
+			t.Errorf("Expected file at %s", path)
+		}
+	}
+	if helpers.FileExists(filepath.Join(tempDir, "movie2.en.srt")) {
+		t.Error("Expected the sidecar of the duplicate to move with it")
+	}
+}
@@ -0,0 +1,92 @@
+// Package service - destination templates
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mohamedation/GoSorter/helpers"
+	"github.com/mohamedation/GoSorter/model"
+)
+
+// unknownFolder - folder for a template segment without a value
+const unknownFolder = "Unknown"
+
+// templateValues - values for the tokens used in template, hashing and EXIF only when asked for
+func (fp *FileProcessor) templateValues(ctx context.Context, file model.FileDetail, category, template string) map[string]string {
+	values := make(map[string]string)
+	for _, token := range model.TemplateTokensIn(template) {
+		if _, done := values[token]; done {
+			continue
+		}
+		switch token {
+		case "category":
+			// category may contain subfolders ("3D/STLs"), keep them
+			values[token] = fp.datedCategory(file, category)
+		case "ext":
+			values[token] = helpers.SanitizeName(strings.TrimPrefix(file.Ext, "."))
+		case "name":
+			values[token] = helpers.SanitizeName(strings.TrimSuffix(file.Name, file.Ext))
+		case "yyyy", "yy", "mm", "dd":
+			date := helpers.FileDate(file.Path, fp.extConfig.DateSource)
+			for _, t := range []string{"yyyy", "yy", "mm", "dd"} {
+				values[t] = helpers.ExpandDateTokens("{"+t+"}", date)
+			}
+		case "size_bucket":
+			var size int64
+			if info, err := os.Stat(file.Path); err == nil {
+				size = info.Size()
+			}
+			values[token] = helpers.SizeBucket(size)
+		case "hash8":
+			values[token] = fp.shortHash(ctx, file)
+		case "exif.model":
+			camera := ""
+			if exif, err := helpers.ReadExif(file.Path); err == nil {
+				camera = helpers.SanitizeName(exif.Model)
+			}
+			if camera == "" {
+				camera = unknownCamera
+			}
+			values[token] = camera
+		case "artist", "album", "title", "track":
+			for k, v := range fp.musicValues(file) {
+				values[k] = v
+			}
+		case "doc.title", "doc.author", "doc.producer":
+			for k, v := range fp.documentValues(file) {
+				values[k] = v
+			}
+		}
+	}
+	return values
+}
+
+// category with its own date tokens expanded, without the global date_subfolders
+func (fp *FileProcessor) datedCategory(file model.FileDetail, category string) string {
+	if !helpers.HasDateTokens(category) {
+		return category
+	}
+	return helpers.ExpandDateTokens(category, helpers.FileDate(file.Path, fp.extConfig.DateSource))
+}
+
+func (fp *FileProcessor) shortHash(ctx context.Context, file model.FileDetail) string {
+	maxBytes := fp.config.MaxHashFileSizeMB
+	if maxBytes <= 0 {
+		maxBytes = 1024
+	}
+	maxBytes = maxBytes * 1024 * 1024
+
+	hash, err := helpers.HashFile(ctx, file.Path, maxBytes, *fp.config, fp.Logger)
+	if err == nil && hash == "" {
+		// too large for a full hash, the first 4KB still make a stable name
+		hash, err = helpers.PartialHashFile(ctx, file.Path, 4096, *fp.config, fp.Logger)
+	}
+	if err != nil || len(hash) < 8 {
+		fp.Logger.Log(*fp.config, helpers.Debug, fmt.Sprintf("Could not hash %s for {hash8}: %v\n", file.Name, err))
+		return "00000000"
+	}
+	return hash[:8]
+}
@@ -0,0 +1,126 @@
+// Package service - destination template tests
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mohamedation/GoSorter/helpers"
+	"github.com/mohamedation/GoSorter/model"
+)
+
+func TestFileProcessor_DestinationTemplates(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_templates")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	testFiles := []string{"statement.pdf", "notes.txt"}
+	mtime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.Local)
+	for _, file := range testFiles {
+		filePath := filepath.Join(tempDir, file)
+		if err := os.WriteFile(filePath, []byte(file), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", file, err)
+		}
+		if err := os.Chtimes(filePath, mtime, mtime); err != nil {
+			t.Fatalf("Failed to set mtime: %v", err)
+		}
+	}
+
+	config := &model.Config{Silent: true}
+	stats := &model.Stats{StartTime: time.Now()}
+	processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+	processor.extConfig.DestinationTemplate = model.DestinationTemplate{Folder: "{category}/{ext}/{size_bucket}"}
+	processor.extConfig.DestinationTemplates = map[string]model.DestinationTemplate{
+		".pdf": {Folder: "{category}/{yyyy}", FileName: "{yyyy}-{mm}-{dd}_{name}"},
+	}
+
+	if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+		t.Fatalf("ProcessDirectory with templates failed: %v", err)
+	}
+
+	expected := []string{
+		filepath.Join(tempDir, "PDFs", "2024", "2024-03-01_statement.pdf"),
+		filepath.Join(tempDir, "Documents", "txt", "0-1MB", "notes.txt"),
+	}
+	for _, path := range expected {
+		if !helpers.FileExists(path) {
+			t.Errorf("Expected file at %s", path)
+		}
+	}
+}
+
+func TestFileProcessor_InvalidTemplate(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_bad_template")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	filePath := filepath.Join(tempDir, "notes.txt")
+	if err := os.WriteFile(filePath, []byte("notes"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	config := &model.Config{Silent: true}
+	stats := &model.Stats{StartTime: time.Now()}
+	processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+	processor.extConfig.DestinationTemplate = model.DestinationTemplate{Folder: "{category}/{year}"}
+
+	if err := processor.ProcessDirectory(context.Background(), tempDir); err == nil {
+		t.Error("Expected an error for an unknown template token")
+	}
+	if !helpers.FileExists(filePath) {
+		t.Error("Expected no files to be moved with an invalid configuration")
+	}
+}
+
+func TestFileProcessor_TemplateEmptySegment(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_template_empty")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	// stems of only dots expand {name} to nothing
+	for _, name := range []string{".pdf", "...pdf"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", name, err)
+		}
+	}
+
+	config := &model.Config{Silent: true}
+	stats := &model.Stats{StartTime: time.Now()}
+	processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+	processor.extConfig.DestinationTemplates = map[string]model.DestinationTemplate{".pdf": {Folder: "{name}"}}
+
+	if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+		t.Fatalf("ProcessDirectory failed: %v", err)
+	}
+
+	for _, name := range []string{".pdf", "...pdf"} {
+		content, err := os.ReadFile(filepath.Join(tempDir, "Unknown", name))
+		if err != nil || string(content) != name {
+			t.Errorf("Expected %s in Unknown, got %q (%v)", name, content, err)
+		}
+	}
+	if got := stats.GetErrorsCount(); got != 0 {
+		t.Errorf("Expected no errors, got %d", got)
+	}
+}
@@ -0,0 +1,64 @@
+// Package service - video organization
+package service
+
+import (
+	"fmt"
+
+	"github.com/mohamedation/GoSorter/helpers"
+	"github.com/mohamedation/GoSorter/model"
+)
+
+// containers we can read metadata from
+var videoExtensions = map[string]bool{
+	".mp4":  true,
+	".m4v":  true,
+	".mov":  true,
+	".mkv":  true,
+	".webm": true,
+}
+
+// videoTargetFolder - folder of the first matching video rule, or the mapped folder
+func (fp *FileProcessor) videoTargetFolder(file model.FileDetail, targetFolder string) string {
+	if len(fp.extConfig.VideoRules) == 0 {
+		return targetFolder
+	}
+
+	info, err := helpers.ReadVideoInfo(file.Path)
+	if err != nil {
+		fp.Logger.Log(*fp.config, helpers.Debug, fmt.Sprintf("No video metadata in %s: %v\n", file.Name, err))
+		info = &helpers.VideoInfo{}
+	}
+
+	for _, rule := range fp.extConfig.VideoRules {
+		if !videoRuleMatches(rule, info) {
+			continue
+		}
+		if !helpers.HasDateTokens(rule.Folder) {
+			return rule.Folder
+		}
+		recorded := info.Created.Local()
+		if recorded.IsZero() {
+			recorded = helpers.FileDate(file.Path, fp.extConfig.DateSource)
+		}
+		return helpers.ExpandDateTokens(rule.Folder, recorded)
+	}
+	return targetFolder
+}
+
+// unknown values (no metadata) never satisfy a condition on them
+func videoRuleMatches(rule model.VideoRule, info *helpers.VideoInfo) bool {
+	seconds := info.Duration.Seconds()
+	switch {
+	case rule.MinWidth > 0 && info.Width < rule.MinWidth:
+		return false
+	case rule.MinHeight > 0 && info.Height < rule.MinHeight:
+		return false
+	case rule.MaxHeight > 0 && (info.Height == 0 || info.Height > rule.MaxHeight):
+		return false
+	case rule.MinDuration > 0 && seconds < rule.MinDuration:
+		return false
+	case rule.MaxDuration > 0 && (info.Duration == 0 || seconds >= rule.MaxDuration):
+		return false
+	}
+	return true
+}
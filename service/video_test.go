@@ -0,0 +1,157 @@
+// Package service - video organization tests
+package service
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mohamedation/GoSorter/helpers"
+	"github.com/mohamedation/GoSorter/model"
+)
+
+// buildMP4 - ftyp + moov with mvhd (v0) and one video trak/tkhd (v0)
+func buildMP4(created time.Time, timescale, duration uint32, width, height uint16) []byte {
+	be := binary.BigEndian
+	mvhd := make([]byte, 100)
+	be.PutUint32(mvhd[4:], uint32(created.Sub(time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC))/time.Second))
+	be.PutUint32(mvhd[12:], timescale)
+	be.PutUint32(mvhd[16:], duration)
+
+	tkhd := make([]byte, 84)
+	be.PutUint32(tkhd[76:], uint32(width)<<16)
+	be.PutUint32(tkhd[80:], uint32(height)<<16)
+
+	moov := mp4Box("moov", mp4Box("mvhd", mvhd), mp4Box("trak", mp4Box("tkhd", tkhd)))
+	return append(mp4Box("ftyp", []byte("isom\x00\x00\x02\x00")), moov...)
+}
+
+func ebmlElement(id []byte, payload ...[]byte) []byte {
+	size := 0
+	for _, p := range payload {
+		size += len(p)
+	}
+	out := append([]byte{}, id...)
+	out = append(out, 0x40|byte(size>>8), byte(size))
+	for _, p := range payload {
+		out = append(out, p...)
+	}
+	return out
+}
+
+// buildMKV - EBML header + Segment with Info (duration in ms) and one video track
+func buildMKV(durationMs float64, width, height uint16) []byte {
+	duration := binary.BigEndian.AppendUint64(nil, math.Float64bits(durationMs))
+	info := ebmlElement([]byte{0x15, 0x49, 0xA9, 0x66},
+		ebmlElement([]byte{0x2A, 0xD7, 0xB1}, []byte{0x0F, 0x42, 0x40}),
+		ebmlElement([]byte{0x44, 0x89}, duration),
+	)
+	video := ebmlElement([]byte{0xE0},
+		ebmlElement([]byte{0xB0}, binary.BigEndian.AppendUint16(nil, width)),
+		ebmlElement([]byte{0xBA}, binary.BigEndian.AppendUint16(nil, height)),
+	)
+	tracks := ebmlElement([]byte{0x16, 0x54, 0xAE, 0x6B}, ebmlElement([]byte{0xAE}, video))
+	header := ebmlElement([]byte{0x1A, 0x45, 0xDF, 0xA3}, ebmlElement([]byte{0x42, 0x82}, []byte("webm")))
+	return append(header, ebmlElement([]byte{0x18, 0x53, 0x80, 0x67}, info, tracks)...)
+}
+
+func TestReadVideoInfo(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_video_info")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	created := time.Date(2023, 6, 1, 8, 0, 0, 0, time.UTC)
+	mp4Path := filepath.Join(tempDir, "clip.mp4")
+	if err := os.WriteFile(mp4Path, buildMP4(created, 1000, 12500, 1920, 1080), 0644); err != nil {
+		t.Fatalf("Failed to create test MP4: %v", err)
+	}
+	mkvPath := filepath.Join(tempDir, "film.mkv")
+	if err := os.WriteFile(mkvPath, buildMKV(5400000, 3840, 2160), 0644); err != nil {
+		t.Fatalf("Failed to create test MKV: %v", err)
+	}
+
+	info, err := helpers.ReadVideoInfo(mp4Path)
+	if err != nil {
+		t.Fatalf("ReadVideoInfo(mp4) failed: %v", err)
+	}
+	if info.Width != 1920 || info.Height != 1080 {
+		t.Errorf("Expected 1920x1080, got %dx%d", info.Width, info.Height)
+	}
+	if info.Duration != 12500*time.Millisecond {
+		t.Errorf("Expected duration 12.5s, got %s", info.Duration)
+	}
+	if !info.Created.Equal(created) {
+		t.Errorf("Expected creation time %s, got %s", created, info.Created)
+	}
+
+	info, err = helpers.ReadVideoInfo(mkvPath)
+	if err != nil {
+		t.Fatalf("ReadVideoInfo(mkv) failed: %v", err)
+	}
+	if info.Width != 3840 || info.Height != 2160 {
+		t.Errorf("Expected 3840x2160, got %dx%d", info.Width, info.Height)
+	}
+	if info.Duration != 90*time.Minute {
+		t.Errorf("Expected duration 1h30m, got %s", info.Duration)
+	}
+}
+
+func TestFileProcessor_VideoRules(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "gosorter_test_video_rules")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			t.Fatalf("Failed to remove temp dir: %v", err)
+		}
+	}()
+
+	recorded := time.Date(2023, 6, 1, 8, 0, 0, 0, time.UTC)
+	testFiles := map[string][]byte{
+		"film.mkv":    buildMKV(5400000, 3840, 2160),
+		"clip.mp4":    buildMP4(recorded, 1000, 12500, 1920, 1080),
+		"holiday.mp4": buildMP4(recorded, 600, 600*300, 1920, 1080),
+		"broken.webm": []byte("not a video"),
+	}
+	for file, data := range testFiles {
+		if err := os.WriteFile(filepath.Join(tempDir, file), data, 0644); err != nil {
+			t.Fatalf("Failed to create test file %s: %v", file, err)
+		}
+	}
+
+	config := &model.Config{Silent: true}
+	stats := &model.Stats{StartTime: time.Now()}
+	processor := NewFileProcessor(config, stats, &helpers.CLILogger{})
+	processor.extConfig.VideoRules = []model.VideoRule{
+		{Folder: "Videos/4K", MinHeight: 2160},
+		{Folder: "Videos/Clips", MaxDuration: 30},
+		{Folder: "Videos/{yyyy}", MinDuration: 30},
+	}
+
+	if err := processor.ProcessDirectory(context.Background(), tempDir); err != nil {
+		t.Fatalf("ProcessDirectory with video rules failed: %v", err)
+	}
+
+	expected := []string{
+		filepath.Join(tempDir, "Videos", "4K", "film.mkv"),
+		filepath.Join(tempDir, "Videos", "Clips", "clip.mp4"),
+		filepath.Join(tempDir, "Videos", recorded.Local().Format("2006"), "holiday.mp4"),
+		filepath.Join(tempDir, "Videos", "broken.webm"),
+	}
+	for _, path := range expected {
+		if !helpers.FileExists(path) {
+			t.Errorf("Expected file at %s", path)
+		}
+	}
+}